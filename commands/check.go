@@ -70,14 +70,32 @@ func checkSlackCmdF(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	slackExport, err := slackTransformer.ParseSlackExportFile(zipReader, true)
-	if err != nil {
-		return err
-	}
-
-	err = slackTransformer.Transform(slackExport, "", true, true, false, false, false)
-	if err != nil {
-		return err
+	if slack.IsGridExport(zipReader) {
+		// the teams don't matter for the check
+		slackTransformer.WorkspaceTeams = map[string]string{}
+		for _, workspace := range slack.GridWorkspaceNames(zipReader) {
+			slackTransformer.WorkspaceTeams[workspace] = workspace
+		}
+
+		workspaces, err := slackTransformer.ParseSlackGridExportFile(zipReader, true)
+		if err != nil {
+			return err
+		}
+
+		err = slackTransformer.TransformGrid(workspaces, "", true, true, false, false, false)
+		if err != nil {
+			return err
+		}
+	} else {
+		slackExport, err := slackTransformer.ParseSlackExportFile(zipReader, true)
+		if err != nil {
+			return err
+		}
+
+		err = slackTransformer.Transform(slackExport, "", true, true, false, false, false)
+		if err != nil {
+			return err
+		}
 	}
 
 	slackTransformer.CheckIntermediate()
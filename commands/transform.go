@@ -39,14 +39,37 @@ func init() {
 	}
 	TransformSlackCmd.Flags().StringP("output", "o", "bulk-export.jsonl", "the output path")
 	TransformSlackCmd.Flags().StringP("attachments-dir", "d", "data", "the path for the attachments directory")
-	TransformSlackCmd.Flags().StringP("useroverrides", "", "", "the name of a csv file used to change the MM user profiles extracted from the Slack export. The `apply_to_username` column is required. Optional columns are `username`, `first_name`, `last_name`, `position`, `email` and `password`. An empty field means no override. A single dash in the `first_name`, `last_name` or `position` field means to override with an empty string.")
+	TransformSlackCmd.Flags().StringP("useroverrides", "", "", "the name of a csv file used to change the MM user profiles extracted from the Slack export. The `apply_to_username` column is required. Optional columns are `username`, `first_name`, `last_name`, `nickname`, `position`, `email` and `password`. An empty field means no override. A single dash in the `first_name`, `last_name`, `nickname` or `position` field means to override with an empty string.")
 	TransformSlackCmd.Flags().StringP("channeloverrides", "", "", "the name of a csv file used to change the MM channel profiles extracted from the Slack export. The `apply_to_channel` column is required. Optional columns are `name`, `display_name`, `purpose`, `header` and `topic`. In an optional field, the empty string means no override and a single dash means to override with an empty string.")
+	TransformSlackCmd.Flags().StringP("channeladmins", "", "", "the name of a csv file listing additional channel admins, with the `channel_name` and `username` columns. The creators of the channels are made channel admins as well.")
+	TransformSlackCmd.Flags().StringP("emoji", "", "", "the custom emoji of the Slack workspace, either a JSON file with the response of Slack's emoji.list method or a directory of images named after the emoji")
+	TransformSlackCmd.Flags().StringP("usergroups", "", "", "a JSON file with the response of Slack's usergroups.list method, for user groups missing from the export")
+	TransformSlackCmd.Flags().StringP("usergroups-definition", "", slack.DefaultUsergroupsDefinitionPath, "the output path of the definitions of the imported user groups")
+	TransformSlackCmd.Flags().StringP("bookmarks", "", "", "a JSON file with channel bookmarks missing from the export, either an array of bookmarks or the response of Slack's bookmarks.list method")
 	TransformSlackCmd.Flags().BoolP("skip-convert-posts", "c", false, "Skips converting mentions and post markup. Only for testing purposes")
 	TransformSlackCmd.Flags().BoolP("skip-attachments", "a", false, "Skips copying the attachments from the import file")
 	TransformSlackCmd.Flags().BoolP("allow-download", "l", false, "Allows downloading the attachments for the import file")
 	TransformSlackCmd.Flags().BoolP("add-json-original", "j", false, "Add the raw JSON of the Slack exported post as a prop")
 	TransformSlackCmd.Flags().BoolP("discard-invalid-props", "p", false, "Skips converting posts with invalid props instead discarding the props themselves")
 	TransformSlackCmd.Flags().BoolP("team-internal-only", "i", false, "Transform direct and group message channels into private channels. This can be useful when transforming several Slack workspaces into Mattermost teams on a single Mattermost server, since direct and group messages from different Slack workspaces could otherwise be mixed into the same server-wide channel.")
+	TransformSlackCmd.Flags().String("archived-channels", string(slack.ArchivedChannelsInclude), "What to do with archived Slack channels: \"include\" them as regular channels, \"skip\" them and their posts, or \"separate-report\" to include them and write the list of channels to archive after the import.")
+	TransformSlackCmd.Flags().String("archived-channels-report", slack.DefaultArchivedChannelsReportPath, "the output path of the list of channels to archive, when using --archived-channels separate-report")
+	TransformSlackCmd.Flags().String("admin-policy", string(slack.AdminPolicyTeam), "The Mattermost roles of Slack workspace admins and owners: \"none\" for regular users, \"team\" for team admins, or \"system\" for team admins with owners as system admins as well.")
+	TransformSlackCmd.Flags().String("guest-policy", string(slack.GuestPolicyGuest), "How Slack guests are imported: \"guest\" for Mattermost guest accounts or \"member\" for regular users.")
+	TransformSlackCmd.Flags().String("nickname-field", string(slack.NameFieldDisplayName), "The Slack profile field used as the nickname: \"display_name\", \"display_name_normalized\", \"real_name\", \"real_name_normalized\" or \"none\".")
+	TransformSlackCmd.Flags().String("full-name-fallback-field", string(slack.NameFieldRealName), "The Slack profile field used as the full name of users without a first or last name: \"display_name\", \"display_name_normalized\", \"real_name\", \"real_name_normalized\" or \"none\".")
+	TransformSlackCmd.Flags().String("timezones-script", "", "the output path of a script that sets the timezones of the imported users through the API, as the import can't set them. No script is written by default.")
+	TransformSlackCmd.Flags().Bool("external-users-as-guests", false, "Import users from other organisations, such as those of Slack Connect channels, as guests")
+	TransformSlackCmd.Flags().String("external-users-report", slack.DefaultExternalUsersReportPath, "the output path of the list of users from other organisations")
+	TransformSlackCmd.Flags().StringToString("system-events", map[string]string{}, "How Slack system events are imported, by category, e.g. \"join_leave=skip,topic=keep\". The categories are join_leave, topic, purpose, rename, me, pin and archive, and the modes \"convert\" to Mattermost system posts, \"keep\" as regular messages, or \"skip\". Events are converted by default.")
+	TransformSlackCmd.Flags().String("thread-broadcasts", string(slack.ThreadBroadcastsReply), "How replies also sent to the channel are imported: \"reply\" only in their thread, or \"also-in-channel\" to add them to the channel as well.")
+	TransformSlackCmd.Flags().String("orphaned-replies", string(slack.OrphanedRepliesPlaceholder), "How replies whose root post is missing from the export are imported: \"placeholder\" to attach them to a placeholder root post, \"promote\" to make the first reply the root post, or \"drop\".")
+	TransformSlackCmd.Flags().Bool("keep-inlined-files", false, "Keep the snippets and Slack posts whose content is added to the messages attached to them as well")
+	TransformSlackCmd.Flags().String("external-files-report", slack.DefaultExternalFilesReportPath, "the output path of the list of files shared from other services, such as Google Drive, which are imported as links")
+	TransformSlackCmd.Flags().String("unavailable-content", string(slack.UnavailableContentPlaceholder), "What to do with files and messages missing from the Slack export: add a \"placeholder\" note, leave them out \"silent\"ly, or \"fail\".")
+	TransformSlackCmd.Flags().String("unavailable-content-report", slack.DefaultUnavailableContentReportPath, "the output path of the summary of content missing from the export, by channel")
+	TransformSlackCmd.Flags().StringToString("workspace-team", map[string]string{}, "The existing Mattermost teams to import the workspaces of an Enterprise Grid export into, by workspace directory, e.g. \"engineering=eng,sales=sales-team\". Every workspace must be listed. The channels of the organisation itself are imported into --team.")
+	TransformSlackCmd.Flags().StringArray("export-owner", []string{}, "The Slack ID of the user that made the export, used to import their starred messages as flagged posts. When joining multiple exports, provide this flag once for each file, in the same order.")
 	TransformSlackCmd.Flags().Bool("debug", true, "Whether to show debug logs or not")
 
 	TransformCmd.AddCommand(
@@ -65,14 +88,73 @@ func transformSlackCmdF(cmd *cobra.Command, args []string) error {
 	attachmentsDir, _ := cmd.Flags().GetString("attachments-dir")
 	userOverridesFilename, _ := cmd.Flags().GetString("useroverrides")
 	channelOverridesFilename, _ := cmd.Flags().GetString("channeloverrides")
+	channelAdminsFilename, _ := cmd.Flags().GetString("channeladmins")
+	emojiSource, _ := cmd.Flags().GetString("emoji")
+	usergroupsFilename, _ := cmd.Flags().GetString("usergroups")
+	usergroupsDefinitionPath, _ := cmd.Flags().GetString("usergroups-definition")
+	bookmarksFilename, _ := cmd.Flags().GetString("bookmarks")
 	skipConvertPosts, _ := cmd.Flags().GetBool("skip-convert-posts")
 	skipAttachments, _ := cmd.Flags().GetBool("skip-attachments")
 	allowDownload, _ := cmd.Flags().GetBool("allow-download")
 	addOriginal, _ := cmd.Flags().GetBool("add-json-original")
 	discardInvalidProps, _ := cmd.Flags().GetBool("discard-invalid-props")
 	teamInternalOnly, _ := cmd.Flags().GetBool("team-internal-only")
+	archivedChannels, _ := cmd.Flags().GetString("archived-channels")
+	archivedChannelsReportPath, _ := cmd.Flags().GetString("archived-channels-report")
+	adminPolicy, _ := cmd.Flags().GetString("admin-policy")
+	guestPolicy, _ := cmd.Flags().GetString("guest-policy")
+	nicknameField, _ := cmd.Flags().GetString("nickname-field")
+	fullNameFallbackField, _ := cmd.Flags().GetString("full-name-fallback-field")
+	timezonesScriptPath, _ := cmd.Flags().GetString("timezones-script")
+	externalUsersAsGuests, _ := cmd.Flags().GetBool("external-users-as-guests")
+	externalUsersReportPath, _ := cmd.Flags().GetString("external-users-report")
+	systemEventsFlag, _ := cmd.Flags().GetStringToString("system-events")
+	threadBroadcasts, _ := cmd.Flags().GetString("thread-broadcasts")
+	orphanedReplies, _ := cmd.Flags().GetString("orphaned-replies")
+	keepInlinedFiles, _ := cmd.Flags().GetBool("keep-inlined-files")
+	externalFilesReportPath, _ := cmd.Flags().GetString("external-files-report")
+	unavailableContent, _ := cmd.Flags().GetString("unavailable-content")
+	unavailableContentReportPath, _ := cmd.Flags().GetString("unavailable-content-report")
+	workspaceTeams, _ := cmd.Flags().GetStringToString("workspace-team")
+	exportOwners, _ := cmd.Flags().GetStringArray("export-owner")
 	debug, _ := cmd.Flags().GetBool("debug")
 
+	if !slack.ArchivedChannelsMode(archivedChannels).IsValid() {
+		return fmt.Errorf("Invalid archived channels mode \"%s\"", archivedChannels)
+	}
+	if !slack.AdminPolicy(adminPolicy).IsValid() {
+		return fmt.Errorf("Invalid admin policy \"%s\"", adminPolicy)
+	}
+	if !slack.GuestPolicy(guestPolicy).IsValid() {
+		return fmt.Errorf("Invalid guest policy \"%s\"", guestPolicy)
+	}
+	if !slack.NameField(nicknameField).IsValid() {
+		return fmt.Errorf("Invalid nickname field \"%s\"", nicknameField)
+	}
+	if !slack.NameField(fullNameFallbackField).IsValid() {
+		return fmt.Errorf("Invalid full name fallback field \"%s\"", fullNameFallbackField)
+	}
+	if !slack.ThreadBroadcastsMode(threadBroadcasts).IsValid() {
+		return fmt.Errorf("Invalid thread broadcasts mode \"%s\"", threadBroadcasts)
+	}
+	if !slack.OrphanedRepliesMode(orphanedReplies).IsValid() {
+		return fmt.Errorf("Invalid orphaned replies mode \"%s\"", orphanedReplies)
+	}
+	if !slack.UnavailableContentMode(unavailableContent).IsValid() {
+		return fmt.Errorf("Invalid unavailable content mode \"%s\"", unavailableContent)
+	}
+
+	systemEvents := map[slack.SystemEventCategory]slack.SystemEventsMode{}
+	for category, mode := range systemEventsFlag {
+		if !slack.SystemEventCategory(category).IsValid() {
+			return fmt.Errorf("Invalid system event category \"%s\"", category)
+		}
+		if !slack.SystemEventsMode(mode).IsValid() {
+			return fmt.Errorf("Invalid system events mode \"%s\"", mode)
+		}
+		systemEvents[slack.SystemEventCategory(category)] = slack.SystemEventsMode(mode)
+	}
+
 	// output file
 	if fileInfo, err := os.Stat(outputFilePath); err != nil && !os.IsNotExist(err) {
 		return err
@@ -117,6 +199,16 @@ func transformSlackCmdF(cmd *cobra.Command, args []string) error {
 		zipReaders[i] = zipReader
 	}
 
+	gridExport := false
+	for _, zipReader := range zipReaders {
+		if slack.IsGridExport(zipReader) {
+			gridExport = true
+		}
+	}
+	if gridExport && len(zipReaders) > 1 {
+		return fmt.Errorf("Enterprise Grid exports can't be joined with other exports")
+	}
+
 	// user overrides
 	var userOverridesFile *os.File
 	if userOverridesFilename != "" {
@@ -139,37 +231,109 @@ func transformSlackCmdF(cmd *cobra.Command, args []string) error {
 		defer channelOverridesFile.Close()
 	}
 
+	// channel admins
+	var channelAdminsFile *os.File
+	if channelAdminsFilename != "" {
+		var err error
+		channelAdminsFile, err = os.Open(channelAdminsFilename)
+		if err != nil {
+			return err
+		}
+		defer channelAdminsFile.Close()
+	}
+
 	logger := log.New()
 	if debug {
 		logger.Level = log.DebugLevel
 	}
 	slackTransformer := slack.NewTransformer(team, logger)
+	slackTransformer.ArchivedChannels = slack.ArchivedChannelsMode(archivedChannels)
+	slackTransformer.ArchivedChannelsReportPath = archivedChannelsReportPath
+	slackTransformer.AdminPolicy = slack.AdminPolicy(adminPolicy)
+	slackTransformer.GuestPolicy = slack.GuestPolicy(guestPolicy)
+	slackTransformer.NicknameField = slack.NameField(nicknameField)
+	slackTransformer.FullNameFallbackField = slack.NameField(fullNameFallbackField)
+	slackTransformer.TimezonesScriptPath = timezonesScriptPath
+	slackTransformer.UsergroupsDefinitionPath = usergroupsDefinitionPath
+	slackTransformer.ExternalUsersAsGuests = externalUsersAsGuests
+	slackTransformer.ExternalUsersReportPath = externalUsersReportPath
+	slackTransformer.SystemEvents = systemEvents
+	slackTransformer.ThreadBroadcasts = slack.ThreadBroadcastsMode(threadBroadcasts)
+	slackTransformer.OrphanedReplies = slack.OrphanedRepliesMode(orphanedReplies)
+	slackTransformer.KeepInlinedFiles = keepInlinedFiles
+	slackTransformer.ExternalFilesReportPath = externalFilesReportPath
+	slackTransformer.UnavailableContent = slack.UnavailableContentMode(unavailableContent)
+	slackTransformer.UnavailableContentReportPath = unavailableContentReportPath
 
-	slackExports := make([]*slack.SlackExport, len(zipReaders))
-	for i, zipReader := range zipReaders {
-		slackExport, err := slackTransformer.ParseSlackExportFile(zipReader, skipConvertPosts)
+	if usergroupsFilename != "" {
+		if err := slackTransformer.ParseUsergroupsFile(usergroupsFilename); err != nil {
+			return err
+		}
+	}
+
+	if bookmarksFilename != "" {
+		if err := slackTransformer.ParseBookmarksFile(bookmarksFilename); err != nil {
+			return err
+		}
+	}
+
+	slackTransformer.WorkspaceTeams = workspaceTeams
+
+	var slackExport *slack.SlackExport
+	var workspaces []*slack.SlackWorkspaceExport
+	var err error
+	if gridExport {
+		if len(exportOwners) > 0 {
+			slackTransformer.ExportOwner = exportOwners[0]
+		}
+		workspaces, err = slackTransformer.ParseSlackGridExportFile(zipReaders[0], skipConvertPosts)
+		if err != nil {
+			return err
+		}
+	} else {
+		slackExports := make([]*slack.SlackExport, len(zipReaders))
+		for i, zipReader := range zipReaders {
+			slackTransformer.ExportOwner = ""
+			if i < len(exportOwners) {
+				slackTransformer.ExportOwner = exportOwners[i]
+			}
+			slackExports[i], err = slackTransformer.ParseSlackExportFile(zipReader, skipConvertPosts)
+			if err != nil {
+				return err
+			}
+		}
+
+		slackExport, err = slackTransformer.MergeSlackExports(slackExports)
 		if err != nil {
 			return err
 		}
-		slackExports[i] = slackExport
 	}
 
-	slackExport, err := slackTransformer.MergeSlackExports(slackExports)
+	err = slackTransformer.ParseUserOverrides(userOverridesFile)
 	if err != nil {
 		return err
 	}
 
-	err = slackTransformer.ParseUserOverrides(userOverridesFile)
+	err = slackTransformer.ParseChannelOverrides(channelOverridesFile)
 	if err != nil {
 		return err
 	}
 
-	err = slackTransformer.ParseChannelOverrides(channelOverridesFile)
+	err = slackTransformer.ParseChannelAdmins(channelAdminsFile)
+	if err != nil {
+		return err
+	}
+
+	err = slackTransformer.ParseCustomEmoji(emojiSource)
 	if err != nil {
 		return err
 	}
 
-	err = slackTransformer.Transform(slackExport, attachmentsDir, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly)
+	if gridExport {
+		err = slackTransformer.TransformGrid(workspaces, attachmentsDir, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly)
+	} else {
+		err = slackTransformer.Transform(slackExport, attachmentsDir, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly)
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// ArchivedChannelsMode controls what happens with channels that are archived
+// in Slack, as the bulk import format cannot archive channels.
+type ArchivedChannelsMode string
+
+const (
+	// ArchivedChannelsInclude imports archived channels as regular channels.
+	ArchivedChannelsInclude ArchivedChannelsMode = "include"
+	// ArchivedChannelsSkip leaves archived channels and their posts out of
+	// the import.
+	ArchivedChannelsSkip ArchivedChannelsMode = "skip"
+	// ArchivedChannelsSeparateReport imports archived channels as regular
+	// channels and writes a list of the channels to archive after the import.
+	ArchivedChannelsSeparateReport ArchivedChannelsMode = "separate-report"
+)
+
+func (m ArchivedChannelsMode) IsValid() bool {
+	return m == ArchivedChannelsInclude || m == ArchivedChannelsSkip || m == ArchivedChannelsSeparateReport
+}
+
+const DefaultArchivedChannelsReportPath = "archived-channels.json"
+
+type ArchivedChannel struct {
+	Team        string            `json:"team"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name"`
+	Type        model.ChannelType `json:"type"`
+}
+
+func (t *Transformer) getArchivedChannels() []ArchivedChannel {
+	archivedChannels := []ArchivedChannel{}
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+		for _, channel := range channels {
+			if !channel.IsArchived {
+				continue
+			}
+			archivedChannels = append(archivedChannels, ArchivedChannel{
+				Team:        t.TeamName,
+				Name:        channel.Name,
+				DisplayName: channel.DisplayName,
+				Type:        channel.Type,
+			})
+		}
+	}
+	return archivedChannels
+}
+
+// ExportArchivedChannels writes the list of imported channels that should be
+// archived once the import has finished.
+func (t *Transformer) ExportArchivedChannels(writer io.Writer) error {
+	b, err := json.MarshalIndent(t.getArchivedChannels(), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "An error occurred marshalling the archived channels.")
+	}
+
+	if _, err := writer.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "An error occurred writing the archived channels.")
+	}
+
+	return nil
+}
+
+func (t *Transformer) ExportArchivedChannelsReport(reportFilePath string) error {
+	return t.writeReport("the list of archived channels", reportFilePath, DefaultArchivedChannelsReportPath, t.ExportArchivedChannels)
+}
@@ -0,0 +1,253 @@
+package slack
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SlackBlock is a Block Kit block. Only rich_text blocks are rendered, so the
+// elements are kept raw as their shape depends on the type of the block.
+type SlackBlock struct {
+	Type     string          `json:"type"`
+	Elements json.RawMessage `json:"elements"`
+}
+
+type SlackRichTextElement struct {
+	Type     string                 `json:"type"`
+	Elements []SlackRichTextElement `json:"elements"`
+	// Style is a string for lists and an object for inline elements
+	Style       json.RawMessage `json:"style"`
+	Indent      int             `json:"indent"`
+	Offset      int             `json:"offset"`
+	Text        string          `json:"text"`
+	URL         string          `json:"url"`
+	UserId      string          `json:"user_id"`
+	ChannelId   string          `json:"channel_id"`
+	UsergroupId string          `json:"usergroup_id"`
+	Name        string          `json:"name"`
+	SkinTone    int             `json:"skin_tone"`
+	Range       string          `json:"range"`
+	Fallback    string          `json:"fallback"`
+	Value       string          `json:"value"`
+}
+
+type SlackRichTextStyle struct {
+	Bold   bool `json:"bold"`
+	Italic bool `json:"italic"`
+	Strike bool `json:"strike"`
+	Code   bool `json:"code"`
+}
+
+func (e *SlackRichTextElement) textStyle() SlackRichTextStyle {
+	var style SlackRichTextStyle
+	if len(e.Style) > 0 && e.Style[0] == '{' {
+		_ = json.Unmarshal(e.Style, &style)
+	}
+	return style
+}
+
+func (e *SlackRichTextElement) listStyle() string {
+	var style string
+	if len(e.Style) > 0 && e.Style[0] == '"' {
+		_ = json.Unmarshal(e.Style, &style)
+	}
+	return style
+}
+
+// richTextRenderer renders rich_text blocks into Mattermost Markdown.
+// Mentions of unknown users and channels, and of user groups, are left in
+// Slack's format so that later conversions can handle them.
+type richTextRenderer struct {
+	usernames    map[string]string
+	channelNames map[string]string
+	builder      strings.Builder
+}
+
+var broadcastMentions = map[string]string{
+	"here":     "@here",
+	"channel":  "@channel",
+	"everyone": "@all",
+}
+
+// wrapStyle wraps the text in the given Markdown marker, keeping surrounding
+// whitespace outside of it, as Markdown requires.
+func wrapStyle(text, marker string) string {
+	trimmed := strings.TrimFunc(text, unicode.IsSpace)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + marker + trimmed + marker + text[start+len(trimmed):]
+}
+
+func wrapCode(text string) string {
+	if strings.Contains(text, "`") {
+		return wrapStyle(text, "``")
+	}
+	return wrapStyle(text, "`")
+}
+
+func (r *richTextRenderer) renderInline(element SlackRichTextElement, preformatted bool) string {
+	var text string
+	switch element.Type {
+	case "text":
+		text = element.Text
+	case "link":
+		if preformatted || element.Text == "" || element.Text == element.URL {
+			text = element.URL
+		} else {
+			text = "[" + element.Text + "](" + element.URL + ")"
+		}
+	case "user":
+		if username, ok := r.usernames[element.UserId]; ok {
+			text = "@" + username
+		} else {
+			text = "<@" + element.UserId + ">"
+		}
+	case "channel":
+		if channelName, ok := r.channelNames[element.ChannelId]; ok {
+			text = "~" + channelName
+		} else {
+			text = "<#" + element.ChannelId + ">"
+		}
+	case "usergroup":
+		text = "<!subteam^" + element.UsergroupId + ">"
+	case "broadcast":
+		text = broadcastMentions[element.Range]
+	case "emoji":
+		text = ":" + element.Name + ":"
+		if element.SkinTone > 1 {
+			text += ":skin-tone-" + strconv.Itoa(element.SkinTone) + ":"
+		}
+	case "date":
+		text = element.Fallback
+	case "color":
+		text = element.Value
+	default:
+		text = element.Text
+	}
+
+	if preformatted || element.Type != "text" && element.Type != "link" {
+		return text
+	}
+
+	style := element.textStyle()
+	if style.Code {
+		return wrapCode(text)
+	}
+	if style.Strike {
+		text = wrapStyle(text, "~~")
+	}
+	if style.Italic {
+		text = wrapStyle(text, "_")
+	}
+	if style.Bold {
+		text = wrapStyle(text, "**")
+	}
+	return text
+}
+
+func (r *richTextRenderer) renderInlines(elements []SlackRichTextElement, preformatted bool) string {
+	var b strings.Builder
+	for _, element := range elements {
+		b.WriteString(r.renderInline(element, preformatted))
+	}
+	return b.String()
+}
+
+// startBlock makes sure that a block level element starts on its own line.
+func (r *richTextRenderer) startBlock() {
+	if r.builder.Len() > 0 && !strings.HasSuffix(r.builder.String(), "\n") {
+		r.builder.WriteString("\n")
+	}
+}
+
+func (r *richTextRenderer) renderElement(element SlackRichTextElement) {
+	switch element.Type {
+	case "rich_text_section":
+		r.builder.WriteString(r.renderInlines(element.Elements, false))
+	case "rich_text_list":
+		r.startBlock()
+		indent := strings.Repeat("    ", element.Indent)
+		for i, item := range element.Elements {
+			marker := "- "
+			if element.listStyle() == "ordered" {
+				marker = strconv.Itoa(element.Offset+i+1) + ". "
+			}
+			r.builder.WriteString(indent + marker + r.renderInlines(item.Elements, false) + "\n")
+		}
+	case "rich_text_quote":
+		r.startBlock()
+		text := strings.TrimSuffix(r.renderInlines(element.Elements, false), "\n")
+		for _, line := range strings.Split(text, "\n") {
+			r.builder.WriteString("> " + line + "\n")
+		}
+	case "rich_text_preformatted":
+		r.startBlock()
+		text := strings.TrimSuffix(r.renderInlines(element.Elements, true), "\n")
+		r.builder.WriteString("```\n" + text + "\n```\n")
+	default:
+		r.builder.WriteString(r.renderInlines(element.Elements, false))
+	}
+}
+
+// SlackRenderRichText renders the rich_text blocks of a post into Markdown. It
+// returns false if the post has no rich_text blocks.
+func SlackRenderRichText(blocks []SlackBlock, usernames, channelNames map[string]string) (string, bool) {
+	r := &richTextRenderer{
+		usernames:    usernames,
+		channelNames: channelNames,
+	}
+
+	found := false
+	for _, block := range blocks {
+		if block.Type != "rich_text" {
+			continue
+		}
+		var elements []SlackRichTextElement
+		if err := json.Unmarshal(block.Elements, &elements); err != nil {
+			return "", false
+		}
+		found = true
+		r.startBlock()
+		for _, element := range elements {
+			r.renderElement(element)
+		}
+	}
+
+	return strings.TrimRight(r.builder.String(), "\n"), found
+}
+
+// SlackConvertBlocks replaces the text of the posts that have rich_text blocks
+// with the Markdown rendering of the blocks, as the text of those posts is only
+// a fallback.
+func SlackConvertBlocks(users []SlackUser, channels []SlackChannel, posts map[string][]SlackPost) map[string][]SlackPost {
+	usernames := make(map[string]string, len(users))
+	for _, user := range users {
+		usernames[user.Id] = user.Username
+	}
+	channelNames := make(map[string]string, len(channels))
+	for _, channel := range channels {
+		if channel.Name != "" {
+			channelNames[channel.Id] = channel.Name
+		}
+	}
+
+	for channelName, channelPosts := range posts {
+		for postIdx, post := range channelPosts {
+			if len(post.Blocks) == 0 {
+				continue
+			}
+			text, ok := SlackRenderRichText(post.Blocks, usernames, channelNames)
+			if !ok {
+				continue
+			}
+			posts[channelName][postIdx].Text = text
+			posts[channelName][postIdx].TextFromBlocks = true
+		}
+	}
+
+	return posts
+}
@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackRenderRichText(t *testing.T) {
+	usernames := map[string]string{"U1": "alice"}
+	channelNames := map[string]string{"C1": "general"}
+
+	testCases := []struct {
+		Name     string
+		Blocks   string
+		Expected string
+	}{
+		{
+			Name: "Styled spans and mentions",
+			Blocks: `[{"type": "rich_text", "elements": [{"type": "rich_text_section", "elements": [
+				{"type": "text", "text": "Hey "},
+				{"type": "user", "user_id": "U1"},
+				{"type": "text", "text": ", see "},
+				{"type": "channel", "channel_id": "C1"},
+				{"type": "text", "text": " and "},
+				{"type": "link", "url": "https://example.com", "text": "this", "style": {"bold": true}},
+				{"type": "text", "text": " for the "},
+				{"type": "text", "text": "really ", "style": {"bold": true, "italic": true}},
+				{"type": "text", "text": "old", "style": {"strike": true}},
+				{"type": "text", "text": " "},
+				{"type": "text", "text": "config", "style": {"code": true}},
+				{"type": "text", "text": " "},
+				{"type": "emoji", "name": "wave", "skin_tone": 3},
+				{"type": "broadcast", "range": "here"},
+				{"type": "user", "user_id": "U2"}
+			]}]}]`,
+			Expected: "Hey @alice, see ~general and **[this](https://example.com)** for the **_really_** ~~old~~ `config` :wave::skin-tone-3:@here<@U2>",
+		},
+		{
+			Name: "Nested lists",
+			Blocks: `[{"type": "rich_text", "elements": [
+				{"type": "rich_text_section", "elements": [{"type": "text", "text": "Steps:\n"}]},
+				{"type": "rich_text_list", "style": "ordered", "indent": 0, "elements": [
+					{"type": "rich_text_section", "elements": [{"type": "text", "text": "first"}]}
+				]},
+				{"type": "rich_text_list", "style": "bullet", "indent": 1, "elements": [
+					{"type": "rich_text_section", "elements": [{"type": "text", "text": "detail"}]},
+					{"type": "rich_text_section", "elements": [{"type": "text", "text": "more", "style": {"italic": true}}]}
+				]},
+				{"type": "rich_text_list", "style": "ordered", "indent": 0, "offset": 1, "elements": [
+					{"type": "rich_text_section", "elements": [{"type": "text", "text": "second"}]}
+				]},
+				{"type": "rich_text_section", "elements": [{"type": "text", "text": "Done"}]}
+			]}]`,
+			Expected: "Steps:\n1. first\n    - detail\n    - _more_\n2. second\nDone",
+		},
+		{
+			Name: "Quotes and code blocks",
+			Blocks: `[{"type": "rich_text", "elements": [
+				{"type": "rich_text_quote", "elements": [{"type": "text", "text": "quoted\nlines"}]},
+				{"type": "rich_text_preformatted", "elements": [
+					{"type": "text", "text": "x := *y\n"},
+					{"type": "link", "url": "https://example.com", "text": "link"}
+				]},
+				{"type": "rich_text_section", "elements": [{"type": "text", "text": "after"}]}
+			]}]`,
+			Expected: "> quoted\n> lines\n```\nx := *y\nhttps://example.com\n```\nafter",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			posts, err := SlackParsePosts(strings.NewReader(`[{"type": "message", "text": "fallback", "blocks": ` + tc.Blocks + `}]`))
+			require.NoError(t, err)
+			text, ok := SlackRenderRichText(posts[0].Blocks, usernames, channelNames)
+			require.True(t, ok)
+			assert.Equal(t, tc.Expected, text)
+		})
+	}
+
+	t.Run("Posts without rich_text blocks keep their text", func(t *testing.T) {
+		posts, err := SlackParsePosts(strings.NewReader(`[{"type": "message", "text": "*bold*", "blocks": [{"type": "actions", "elements": [{"type": "button", "text": {"type": "plain_text", "text": "OK"}}]}]}]`))
+		require.NoError(t, err)
+
+		converted := SlackConvertPostsMarkup(SlackConvertBlocks(nil, nil, map[string][]SlackPost{"c": posts}))
+		assert.Equal(t, "**bold**", converted["c"][0].Text)
+		assert.False(t, converted["c"][0].TextFromBlocks)
+	})
+
+	t.Run("Rendered posts skip the markup conversion", func(t *testing.T) {
+		posts, err := SlackParsePosts(strings.NewReader(`[{"type": "message", "text": "*bold*", "blocks": [{"type": "rich_text", "elements": [{"type": "rich_text_section", "elements": [{"type": "text", "text": "bold", "style": {"bold": true}}]}]}]}]`))
+		require.NoError(t, err)
+
+		converted := SlackConvertPostsMarkup(SlackConvertBlocks(nil, nil, map[string][]SlackPost{"c": posts}))
+		assert.Equal(t, "**bold**", converted["c"][0].Text)
+		assert.True(t, converted["c"][0].TextFromBlocks)
+	})
+}
@@ -0,0 +1,205 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+const bookmarksPostTitle = "Channel bookmarks"
+
+type SlackBookmark struct {
+	Id          string `json:"id"`
+	ChannelId   string `json:"channel_id"`
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	Emoji       string `json:"emoji"`
+	Type        string `json:"type"`
+	Rank        string `json:"rank"`
+	DateCreated int64  `json:"date_created"`
+	DateUpdated int64  `json:"date_updated"`
+}
+
+// slackBookmarkList is the shape of the response of Slack's bookmarks.list
+// method.
+type slackBookmarkList struct {
+	Bookmarks []SlackBookmark `json:"bookmarks"`
+}
+
+// SlackParseBookmarks reads bookmarks either from a bookmarks.list response
+// or from a plain array of bookmarks. Each bookmark must have its channel_id.
+func SlackParseBookmarks(data io.Reader) ([]SlackBookmark, error) {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookmarkList slackBookmarkList
+	if err := json.Unmarshal(b, &bookmarkList); err == nil {
+		return bookmarkList.Bookmarks, nil
+	}
+
+	var bookmarks []SlackBookmark
+	if err := json.Unmarshal(b, &bookmarks); err != nil {
+		return nil, errors.Wrap(err, "bookmarks file must contain a bookmarks.list response or an array of bookmarks")
+	}
+	return bookmarks, nil
+}
+
+// ParseBookmarksFile reads channel bookmarks supplied apart from the export.
+// They are added to the bookmarks of the channels in the export.
+func (t *Transformer) ParseBookmarksFile(bookmarksFile string) error {
+	file, err := os.Open(bookmarksFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	t.Bookmarks, err = SlackParseBookmarks(file)
+	if err != nil {
+		t.Logger.Error(err.Error())
+		return err
+	}
+	t.Logger.Infof("Parsed %d channel bookmarks", len(t.Bookmarks))
+	return nil
+}
+
+// addSuppliedBookmarks adds the supplied bookmarks to their channels, unless
+// the channel already has a bookmark with the same ID.
+func addSuppliedBookmarks(channels []SlackChannel, bookmarks []SlackBookmark) {
+	if len(bookmarks) == 0 {
+		return
+	}
+	for i := range channels {
+		for _, bookmark := range bookmarks {
+			if bookmark.ChannelId != channels[i].Id {
+				continue
+			}
+			found := false
+			for _, existing := range channels[i].Bookmarks {
+				if existing.Id == bookmark.Id {
+					found = true
+					break
+				}
+			}
+			if !found {
+				channels[i].Bookmarks = append(channels[i].Bookmarks, bookmark)
+			}
+		}
+	}
+}
+
+var bookmarkTitleEscaper = strings.NewReplacer("[", `\[`, "]", `\]`)
+
+// SlackRenderBookmarks renders the link bookmarks of a channel as a Markdown
+// list, in the order they have in Slack.
+func SlackRenderBookmarks(bookmarks []SlackBookmark) string {
+	links := []SlackBookmark{}
+	for _, bookmark := range bookmarks {
+		if bookmark.Link != "" {
+			links = append(links, bookmark)
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Rank != links[j].Rank {
+			return links[i].Rank < links[j].Rank
+		}
+		return links[i].DateCreated < links[j].DateCreated
+	})
+
+	lines := make([]string, 0, len(links))
+	for _, link := range links {
+		line := "- "
+		if link.Emoji != "" {
+			line += link.Emoji + " "
+		}
+		title := stringOrDefault(strings.TrimSpace(link.Title), link.Link)
+		line += "[" + bookmarkTitleEscaper.Replace(title) + "](" + link.Link + ")"
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastBookmarkUpdate returns the time in milliseconds of the last change to
+// the bookmarks, or zero if it is unknown.
+func lastBookmarkUpdate(bookmarks []SlackBookmark) int64 {
+	var last int64
+	for _, bookmark := range bookmarks {
+		if bookmark.DateCreated > last {
+			last = bookmark.DateCreated
+		}
+		if bookmark.DateUpdated > last {
+			last = bookmark.DateUpdated
+		}
+	}
+	return last * 1000
+}
+
+// addBookmarksToChannel appends the bookmarks of the channel to its header.
+// When the header is overridden, or the bookmarks don't fit in it, they are
+// kept to be posted as a pinned post instead, dated by the last change to the
+// bookmarks or by the creation of the channel if their dates are unknown.
+func (t *Transformer) addBookmarksToChannel(channel *IntermediateChannel, bookmarks []SlackBookmark, created int64, headerOverridden bool) {
+	list := SlackRenderBookmarks(bookmarks)
+	if list == "" {
+		return
+	}
+
+	header := list
+	if channel.Header != "" {
+		header = channel.Header + "\n\n" + list
+	}
+	if !headerOverridden && utf8.RuneCountInString(header) <= model.ChannelHeaderMaxRunes {
+		channel.Header = header
+		return
+	}
+
+	t.Logger.Infof("Channel %s bookmarks don't fit in the header. They will be imported as a pinned post.", channel.Name)
+	channel.Bookmarks = list
+	channel.BookmarksUpdateAt = lastBookmarkUpdate(bookmarks)
+	if channel.BookmarksUpdateAt == 0 {
+		channel.BookmarksUpdateAt = created * 1000
+	}
+}
+
+// TransformBookmarks creates a pinned post for each channel whose bookmarks
+// couldn't be added to the header. The post is made by the creator of the
+// channel, or by its first member if the creator is not imported.
+func (t *Transformer) TransformBookmarks() {
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+		for _, channel := range channels {
+			if channel.Bookmarks == "" {
+				continue
+			}
+
+			author, ok := t.Intermediate.UsersById[channel.Creator]
+			if !ok && len(channel.Members) > 0 {
+				author, ok = t.Intermediate.UsersById[channel.Members[0]]
+			}
+			if !ok {
+				t.Logger.Warnf("Unable to import the bookmarks of channel %s as it has no members", channel.Name)
+				continue
+			}
+
+			createAt := channel.BookmarksUpdateAt
+			if createAt == 0 {
+				// same as bad timestamps, so the output is reproducible
+				t.Logger.Warnf("The bookmarks of channel %s have no date. They will be posted at the start of the channel.", channel.Name)
+				createAt = 1
+			}
+			t.Intermediate.Posts = append(t.Intermediate.Posts, &IntermediatePost{
+				User:     author.Username,
+				Channel:  channel.Name,
+				Message:  "#### " + bookmarksPostTitle + "\n\n" + channel.Bookmarks,
+				CreateAt: createAt,
+				IsPinned: true,
+			})
+		}
+	}
+}
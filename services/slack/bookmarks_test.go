@@ -0,0 +1,123 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackRenderBookmarks(t *testing.T) {
+	bookmarks := []SlackBookmark{
+		{Id: "Bk2", Title: "Runbook [v2]", Link: "https://example.com/runbook", Rank: "b"},
+		{Id: "Bk1", Title: "Dashboard", Link: "https://example.com/dash", Emoji: ":bar_chart:", Rank: "a"},
+		{Id: "Bk3", Title: "Folder", Type: "folder", Rank: "c"},
+		{Id: "Bk4", Link: "https://example.com/untitled", Rank: "d"},
+	}
+
+	assert.Equal(t, "- :bar_chart: [Dashboard](https://example.com/dash)\n"+
+		"- [Runbook \\[v2\\]](https://example.com/runbook)\n"+
+		"- [https://example.com/untitled](https://example.com/untitled)", SlackRenderBookmarks(bookmarks))
+	assert.Equal(t, "", SlackRenderBookmarks(nil))
+}
+
+func TestSlackParseBookmarks(t *testing.T) {
+	bookmarks, err := SlackParseBookmarks(strings.NewReader(`{"ok": true, "bookmarks": [{"id": "Bk1", "channel_id": "C1", "title": "Docs", "link": "https://example.com"}]}`))
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 1)
+	assert.Equal(t, "C1", bookmarks[0].ChannelId)
+
+	bookmarks, err = SlackParseBookmarks(strings.NewReader(`[{"id": "Bk1", "channel_id": "C1"}, {"id": "Bk2", "channel_id": "C2"}]`))
+	require.NoError(t, err)
+	assert.Len(t, bookmarks, 2)
+}
+
+func TestTransformChannelBookmarks(t *testing.T) {
+	users := []SlackUser{
+		{Id: "U1", Username: "alice"},
+		{Id: "U2", Username: "bob"},
+	}
+	bookmarks := []SlackBookmark{{Id: "Bk1", Title: "Docs", Link: "https://example.com/docs", DateCreated: 1600000000}}
+
+	t.Run("Bookmarks are appended to the header", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.TransformUsers(users)
+		channels := slackTransformer.TransformChannels([]SlackChannel{
+			{Id: "C1", Name: "general", Members: []string{"U1", "U2"}, Topic: SlackChannelSub{Value: "Welcome"}, Bookmarks: bookmarks, Type: model.ChannelTypeOpen},
+		}, false)
+
+		require.Len(t, channels, 1)
+		assert.Equal(t, "Welcome\n\n- [Docs](https://example.com/docs)", channels[0].Header)
+		assert.Empty(t, channels[0].Bookmarks)
+	})
+
+	t.Run("Bookmarks that don't fit are posted", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.TransformUsers(users)
+		longTopic := strings.Repeat("a", model.ChannelHeaderMaxRunes-10)
+		slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels([]SlackChannel{
+			{Id: "C1", Name: "general", Creator: "U2", Members: []string{"U1", "U2"}, Topic: SlackChannelSub{Value: longTopic}, Bookmarks: bookmarks, Type: model.ChannelTypeOpen},
+		}, false)
+
+		channel := slackTransformer.Intermediate.PublicChannels[0]
+		assert.Equal(t, longTopic, channel.Header)
+		assert.Equal(t, "- [Docs](https://example.com/docs)", channel.Bookmarks)
+
+		slackTransformer.TransformBookmarks()
+		require.Len(t, slackTransformer.Intermediate.Posts, 1)
+		post := slackTransformer.Intermediate.Posts[0]
+		assert.Equal(t, "bob", post.User)
+		assert.Equal(t, "general", post.Channel)
+		assert.Equal(t, "#### Channel bookmarks\n\n- [Docs](https://example.com/docs)", post.Message)
+		assert.Equal(t, int64(1600000000000), post.CreateAt)
+		assert.True(t, post.IsPinned)
+	})
+
+	t.Run("Undated bookmarks are posted at the creation of the channel", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.TransformUsers(users)
+		slackTransformer.Intermediate.ChannelOverrides = map[string]*IntermediateChannel{
+			"general": {Header: "Overridden"},
+		}
+		slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels([]SlackChannel{
+			{Id: "C1", Name: "general", Creator: "U1", Members: []string{"U1", "U2"}, Created: 1500000000, Bookmarks: []SlackBookmark{{Id: "Bk1", Title: "Docs", Link: "https://example.com/docs"}}, Type: model.ChannelTypeOpen},
+		}, false)
+
+		slackTransformer.TransformBookmarks()
+		require.Len(t, slackTransformer.Intermediate.Posts, 1)
+		assert.Equal(t, int64(1500000000000), slackTransformer.Intermediate.Posts[0].CreateAt)
+	})
+
+	t.Run("Overridden headers are kept", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.TransformUsers(users)
+		slackTransformer.Intermediate.ChannelOverrides = map[string]*IntermediateChannel{
+			"general": {Header: "Overridden"},
+		}
+		channels := slackTransformer.TransformChannels([]SlackChannel{
+			{Id: "C1", Name: "general", Members: []string{"U1", "U2"}, Bookmarks: bookmarks, Type: model.ChannelTypeOpen},
+		}, false)
+
+		require.Len(t, channels, 1)
+		assert.Equal(t, "Overridden", channels[0].Header)
+		assert.Equal(t, "- [Docs](https://example.com/docs)", channels[0].Bookmarks)
+	})
+}
+
+func TestAddSuppliedBookmarks(t *testing.T) {
+	channels := []SlackChannel{
+		{Id: "C1", Bookmarks: []SlackBookmark{{Id: "Bk1", ChannelId: "C1", Title: "From the export"}}},
+		{Id: "C2"},
+	}
+	addSuppliedBookmarks(channels, []SlackBookmark{
+		{Id: "Bk1", ChannelId: "C1", Title: "Supplied"},
+		{Id: "Bk2", ChannelId: "C2", Title: "Supplied"},
+		{Id: "Bk3", ChannelId: "C3", Title: "Supplied"},
+	})
+
+	assert.Equal(t, []SlackBookmark{{Id: "Bk1", ChannelId: "C1", Title: "From the export"}}, channels[0].Bookmarks)
+	assert.Equal(t, []SlackBookmark{{Id: "Bk2", ChannelId: "C2", Title: "Supplied"}}, channels[1].Bookmarks)
+}
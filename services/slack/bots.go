@@ -0,0 +1,64 @@
+package slack
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+type SlackIcons struct {
+	Image36 string `json:"image_36"`
+	Image48 string `json:"image_48"`
+	Image72 string `json:"image_72"`
+	Emoji   string `json:"emoji"`
+}
+
+// url returns the URL of the largest image, or an empty string if there is
+// none.
+func (i *SlackIcons) url() string {
+	if i == nil {
+		return ""
+	}
+	for _, image := range []string{i.Image72, i.Image48, i.Image36} {
+		if image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+type SlackBotProfile struct {
+	Id    string      `json:"id"`
+	Name  string      `json:"name"`
+	Icons *SlackIcons `json:"icons"`
+}
+
+// botProps returns the props that make a bot message show the name and icon
+// it had in Slack. The name is only shown for posts from webhooks, so bot
+// messages are marked as such.
+func (p *SlackPost) botProps() model.StringInterface {
+	props := model.StringInterface{
+		"from_bot":     "true",
+		"from_webhook": "true",
+	}
+
+	username := p.BotUsername
+	if username == "" && p.BotProfile != nil {
+		username = p.BotProfile.Name
+	}
+	if username != "" {
+		props["override_username"] = username
+	}
+
+	iconURL := p.Icons.url()
+	if iconURL == "" && p.BotProfile != nil {
+		iconURL = p.BotProfile.Icons.url()
+	}
+	if iconURL != "" {
+		props[model.PostPropsOverrideIconURL] = iconURL
+	} else if p.Icons != nil && p.Icons.Emoji != "" {
+		props[model.PostPropsOverrideIconEmoji] = strings.Trim(p.Icons.Emoji, ":")
+	}
+
+	return props
+}
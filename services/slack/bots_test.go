@@ -0,0 +1,114 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackPostBotProps(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Post     SlackPost
+		Expected model.StringInterface
+	}{
+		{
+			Name: "Username and icon of the message",
+			Post: SlackPost{
+				BotUsername: "jenkins",
+				Icons:       &SlackIcons{Image48: "https://example.com/48.png", Image72: "https://example.com/72.png"},
+				BotProfile:  &SlackBotProfile{Name: "Jenkins CI", Icons: &SlackIcons{Image72: "https://example.com/bot.png"}},
+			},
+			Expected: model.StringInterface{
+				"from_bot":          "true",
+				"from_webhook":      "true",
+				"override_username": "jenkins",
+				"override_icon_url": "https://example.com/72.png",
+			},
+		},
+		{
+			Name: "Bot profile",
+			Post: SlackPost{
+				BotProfile: &SlackBotProfile{Name: "PagerDuty", Icons: &SlackIcons{Image36: "https://example.com/36.png"}},
+			},
+			Expected: model.StringInterface{
+				"from_bot":          "true",
+				"from_webhook":      "true",
+				"override_username": "PagerDuty",
+				"override_icon_url": "https://example.com/36.png",
+			},
+		},
+		{
+			Name: "Emoji icon",
+			Post: SlackPost{
+				BotUsername: "ghost",
+				Icons:       &SlackIcons{Emoji: ":ghost:"},
+			},
+			Expected: model.StringInterface{
+				"from_bot":            "true",
+				"from_webhook":        "true",
+				"override_username":   "ghost",
+				"override_icon_emoji": "ghost",
+			},
+		},
+		{
+			Name: "Nothing to override",
+			Post: SlackPost{},
+			Expected: model.StringInterface{
+				"from_bot":     "true",
+				"from_webhook": "true",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, tc.Post.botProps())
+		})
+	}
+}
+
+func TestTransformPostsBotMessages(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.TransformUsers([]SlackUser{{Id: "U1", Username: "alice"}, {Id: "U2", Username: "bob"}})
+	slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels([]SlackChannel{
+		{Id: "C1", Name: "alerts", Members: []string{"U1", "U2"}, Type: model.ChannelTypeOpen},
+	}, false)
+
+	slackExport := &SlackExport{
+		Posts: map[string][]SlackPost{
+			"alerts": {{
+				Type:        "message",
+				SubType:     "bot_message",
+				BotId:       "B1",
+				BotUsername: "jenkins",
+				BotProfile:  &SlackBotProfile{Id: "B1", Name: "Jenkins"},
+				Text:        "Build failed",
+				TimeStamp:   "1600000000.000100",
+			}},
+		},
+	}
+	require.NoError(t, slackTransformer.TransformPosts(slackExport, "", true, false, false, false))
+
+	require.Len(t, slackTransformer.Intermediate.Posts, 1)
+	post := slackTransformer.Intermediate.Posts[0]
+	assert.Equal(t, "b1", post.User)
+	assert.Equal(t, "jenkins", post.Props["override_username"])
+	assert.Equal(t, "true", post.Props["from_bot"])
+
+	bot := slackTransformer.Intermediate.UsersById["B1"]
+	assert.Equal(t, "Jenkins", bot.FirstName)
+	assert.Empty(t, bot.LastName)
+}
+
+func TestGetPropsForPostTombstone(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	props, _ := slackTransformer.GetPropsForPost(&SlackPost{Type: "message", SubType: "tombstone", User: "USLACKBOT", Text: "This message was deleted."}, false, false)
+	assert.Nil(t, props)
+
+	props, _ = slackTransformer.GetPropsForPost(&SlackPost{Type: "message", SubType: "bot_message", BotId: "B1"}, false, false)
+	assert.Equal(t, "true", props["from_webhook"])
+}
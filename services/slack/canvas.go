@@ -0,0 +1,460 @@
+package slack
+
+import (
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// IsCanvas returns true if the file is a Slack canvas, whose content is an
+// HTML document.
+func (f *SlackFile) IsCanvas() bool {
+	return f.Filetype == "quip" || f.Filetype == "canvas" || f.Mimetype == "application/vnd.slack-docs"
+}
+
+// canvasRenderer renders the HTML of a canvas into Mattermost Markdown.
+type canvasRenderer struct {
+	builder strings.Builder
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" {
+			for _, c := range strings.Fields(attr.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// collapseWhitespace replaces runs of whitespace with a single space, as
+// HTML rendering does.
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func isBlockElement(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Html, atom.Head, atom.Body, atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Ul, atom.Ol, atom.Pre, atom.Blockquote, atom.Hr, atom.Table:
+		return true
+	}
+	return false
+}
+
+// preformattedText returns the text of a preformatted element, keeping its
+// line breaks.
+func preformattedText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.DataAtom == atom.Br:
+			b.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (r *canvasRenderer) renderInlines(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		// nested lists are rendered by renderList
+		if c.DataAtom == atom.Ul || c.DataAtom == atom.Ol {
+			continue
+		}
+		b.WriteString(r.renderInline(c))
+	}
+	return b.String()
+}
+
+func (r *canvasRenderer) renderInline(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return collapseWhitespace(n.Data)
+	}
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "\n"
+	case atom.B, atom.Strong:
+		return wrapStyle(r.renderInlines(n), "**")
+	case atom.I, atom.Em:
+		return wrapStyle(r.renderInlines(n), "_")
+	case atom.S, atom.Del, atom.Strike:
+		return wrapStyle(r.renderInlines(n), "~~")
+	case atom.Code:
+		return wrapCode(preformattedText(n))
+	case atom.A:
+		text := strings.TrimSpace(r.renderInlines(n))
+		href := getAttr(n, "href")
+		if href == "" {
+			return text
+		}
+		if text == "" || text == href {
+			return href
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		src := getAttr(n, "src")
+		if src == "" {
+			return ""
+		}
+		return "![" + getAttr(n, "alt") + "](" + src + ")"
+	case atom.Script, atom.Style, atom.Title:
+		return ""
+	}
+	return r.renderInlines(n)
+}
+
+// renderLine renders the inline content of an element as trimmed Markdown
+// lines.
+func (r *canvasRenderer) renderLine(n *html.Node) string {
+	lines := strings.Split(r.renderInlines(n), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func (r *canvasRenderer) writeBlock(text string) {
+	if text == "" {
+		return
+	}
+	if r.builder.Len() > 0 {
+		r.builder.WriteString("\n\n")
+	}
+	r.builder.WriteString(text)
+}
+
+func (r *canvasRenderer) renderList(n *html.Node, depth int) string {
+	lines := []string{}
+	indent := strings.Repeat("    ", depth)
+	ordered := n.DataAtom == atom.Ol
+	checklist := hasClass(n, "checklist")
+	number := 1
+	if start, err := strconv.Atoi(getAttr(n, "start")); err == nil {
+		number = start
+	}
+
+	for item := n.FirstChild; item != nil; item = item.NextSibling {
+		if item.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		switch {
+		case checklist && hasClass(item, "checked"):
+			marker = "- [x] "
+		case checklist:
+			marker = "- [ ] "
+		case ordered:
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		lines = append(lines, indent+marker+r.renderLine(item))
+		for c := item.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Ul || c.DataAtom == atom.Ol {
+				lines = append(lines, r.renderList(c, depth+1))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var tableCellEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+func (r *canvasRenderer) renderTable(n *html.Node) string {
+	rows := [][]string{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.DataAtom == atom.Tr {
+			row := []string{}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.DataAtom == atom.Td || c.DataAtom == atom.Th {
+					row = append(row, tableCellEscaper.Replace(r.renderLine(c)))
+				}
+			}
+			rows = append(rows, row)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	lines := []string{}
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderBlocks renders the children of an element, joining consecutive inline
+// children into paragraphs.
+func (r *canvasRenderer) renderBlocks(n *html.Node) {
+	var inlines strings.Builder
+	flush := func() {
+		lines := strings.Split(inlines.String(), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(line)
+		}
+		r.writeBlock(strings.TrimSpace(strings.Join(lines, "\n")))
+		inlines.Reset()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && isBlockElement(c) {
+			flush()
+			r.renderBlock(c)
+			continue
+		}
+		inlines.WriteString(r.renderInline(c))
+	}
+	flush()
+}
+
+func (r *canvasRenderer) renderBlock(n *html.Node) {
+	switch n.DataAtom {
+	case atom.Head:
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		if line := r.renderLine(n); line != "" {
+			r.writeBlock(strings.Repeat("#", level) + " " + strings.ReplaceAll(line, "\n", " "))
+		}
+	case atom.Ul, atom.Ol:
+		r.writeBlock(r.renderList(n, 0))
+	case atom.Pre:
+		r.writeBlock("```\n" + preformattedText(n) + "\n```")
+	case atom.Blockquote:
+		inner := &canvasRenderer{}
+		inner.renderBlocks(n)
+		if text := inner.builder.String(); text != "" {
+			r.writeBlock("> " + strings.ReplaceAll(text, "\n", "\n> "))
+		}
+	case atom.Hr:
+		r.writeBlock("---")
+	case atom.Table:
+		r.writeBlock(r.renderTable(n))
+	default:
+		r.renderBlocks(n)
+	}
+}
+
+// SlackConvertCanvas converts the HTML of a canvas into Markdown.
+func SlackConvertCanvas(data io.Reader) (string, error) {
+	doc, err := html.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	r := &canvasRenderer{}
+	r.renderBlocks(doc)
+	return r.builder.String(), nil
+}
+
+func (p *SlackPost) canvasFiles() []*SlackFile {
+	canvases := []*SlackFile{}
+	if p.File != nil && p.File.IsCanvas() {
+		canvases = append(canvases, p.File)
+	}
+	for _, file := range p.Files {
+		if file.IsCanvas() {
+			canvases = append(canvases, file)
+		}
+	}
+	return canvases
+}
+
+// createCanvasPost creates a pinned post with the content of the canvas as
+// its message and the original canvas attached.
+func (t *Transformer) createCanvasPost(file *SlackFile, newPost *IntermediatePost, slackExport *SlackExport, attachmentsDir string, allowDownload bool) *IntermediatePost {
+	author := newPost.User
+	if owner, ok := t.Intermediate.UsersById[file.User]; ok {
+		author = owner.Username
+	}
+	if t.sharedCanvases == nil {
+		t.sharedCanvases = map[string]bool{}
+	}
+	t.sharedCanvases[file.Id] = true
+
+	canvasPost := &IntermediatePost{
+		User:     author,
+		Channel:  newPost.Channel,
+		CreateAt: newPost.CreateAt,
+		IsPinned: true,
+	}
+
+	if err := addFileToPost(file, slackExport.Uploads, canvasPost, attachmentsDir, allowDownload); err != nil {
+		t.Logger.WithError(err).Errorf("Failed to add canvas %s to post", file.Id)
+		return nil
+	}
+
+	title := stringOrDefault(file.Title, file.Name)
+	message := "#### " + title
+	canvasFile, err := os.Open(path.Join(attachmentsDir, canvasPost.Attachments[0]))
+	if err == nil {
+		defer canvasFile.Close()
+		var content string
+		content, err = SlackConvertCanvas(canvasFile)
+		if content != "" {
+			message += "\n\n" + content
+		}
+	}
+	if err != nil {
+		t.Logger.WithError(err).Warnf("Failed to convert canvas %s. Only the original file will be imported.", file.Id)
+	}
+
+	if utf8.RuneCountInString(message) > model.PostMessageMaxRunesV2 {
+		t.Logger.Warnf("Canvas %s exceeds the maximum post length. It will be truncated, but the original file is attached.", file.Id)
+		message = truncateRunes(message, model.PostMessageMaxRunesV2)
+	}
+	canvasPost.Message = message
+
+	return canvasPost
+}
+
+// AddCanvasesToPost converts the canvases shared in a post into pinned posts.
+// A root post that only shares a canvas becomes the canvas post itself,
+// otherwise the canvas posts are returned to be added next to the post. Replies
+// don't become canvas posts, as pinning a reply pins the root of its thread.
+func (t *Transformer) AddCanvasesToPost(post *SlackPost, skipAttachments bool, slackExport *SlackExport, attachmentsDir string, newPost *IntermediatePost, allowDownload bool) []*IntermediatePost {
+	if skipAttachments {
+		return nil
+	}
+	canvases := post.canvasFiles()
+
+	canvasPosts := []*IntermediatePost{}
+	for _, file := range canvases {
+		if canvasPost := t.createCanvasPost(file, newPost, slackExport, attachmentsDir, allowDownload); canvasPost != nil {
+			canvasPosts = append(canvasPosts, canvasPost)
+		}
+	}
+
+	isReply := post.ThreadTS != "" && post.ThreadTS != post.TimeStamp
+	if !isReply && len(canvasPosts) == 1 && len(canvases) == 1 && strings.TrimSpace(newPost.Message) == "" && len(newPost.Attachments) == 0 {
+		newPost.Message = canvasPosts[0].Message
+		newPost.Attachments = canvasPosts[0].Attachments
+		newPost.IsPinned = true
+		return nil
+	}
+
+	return canvasPosts
+}
+
+// addCanvasPostsToThreads adds the canvas posts created for a post as root
+// posts of the channel.
+func addCanvasPostsToThreads(original SlackPost, canvasPosts []*IntermediatePost, threads map[string]*IntermediatePost, channel *IntermediateChannel, timestamps map[int64]bool) {
+	for i, canvasPost := range canvasPosts {
+		AddPostToThreads(SlackPost{TimeStamp: original.TimeStamp + "-canvas-" + strconv.Itoa(i)}, canvasPost, threads, channel, timestamps)
+	}
+}
+
+const channelCanvasTitle = "Channel canvas"
+
+// TransformChannelCanvases creates a pinned post for the canvas of each
+// channel that is not shared in any of its messages. The post is made by the
+// creator of the channel when the channel was created, as the export only
+// gives the canvas file.
+func (t *Transformer) TransformChannelCanvases(slackExport *SlackExport, attachmentsDir string) {
+	channelsByOriginalName := buildChannelsByOriginalNameMap(t.Intermediate)
+	for _, slackChannel := range slackExport.Channels {
+		canvas := slackChannel.Properties.Canvas
+		if canvas == nil || canvas.FileId == "" || canvas.IsEmpty || t.sharedCanvases[canvas.FileId] {
+			continue
+		}
+		channel, ok := channelsByOriginalName[getOriginalName(slackChannel)]
+		if !ok {
+			continue
+		}
+		zipFile, ok := slackExport.Uploads[canvas.FileId]
+		if !ok {
+			t.Logger.Warnf("Unable to import the canvas of channel %s as it is not part of the export", channel.Name)
+			continue
+		}
+
+		author, ok := t.Intermediate.UsersById[channel.Creator]
+		if !ok && len(channel.Members) > 0 {
+			author, ok = t.Intermediate.UsersById[channel.Members[0]]
+		}
+		if !ok {
+			t.Logger.Warnf("Unable to import the canvas of channel %s as it has no members", channel.Name)
+			continue
+		}
+
+		createAt := slackChannel.Created * 1000
+		if createAt == 0 {
+			// same as bad timestamps, so the output is reproducible
+			createAt = 1
+		}
+		file := &SlackFile{
+			Id:       canvas.FileId,
+			Name:     path.Base(zipFile.Name),
+			Title:    channelCanvasTitle,
+			Filetype: "canvas",
+			User:     author.Id,
+		}
+		channelPost := &IntermediatePost{User: author.Username, Channel: channel.Name, CreateAt: createAt}
+		if canvasPost := t.createCanvasPost(file, channelPost, slackExport, attachmentsDir, false); canvasPost != nil {
+			t.Intermediate.Posts = append(t.Intermediate.Posts, canvasPost)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCanvasHTML = `<html><head><title>Spec</title></head><body>
+<h1>Project spec</h1>
+<p class="line">The <b>goal</b> is to <i>ship</i> <a href="https://example.com">the thing</a>.</p>
+<ul><li>First<ul><li>Nested</li></ul></li><li>Second</li></ul>
+<ol start="3"><li>Third</li><li>Fourth</li></ol>
+<ul class="checklist"><li class="checked">Done</li><li>Todo</li></ul>
+<pre>go build<br>go test</pre>
+<blockquote>Quoted <code>code</code></blockquote>
+<table><tr><th>Name</th><th>Owner</th></tr><tr><td>API</td><td>a|b</td></tr></table>
+</body></html>`
+
+func TestSlackConvertCanvas(t *testing.T) {
+	markdown, err := SlackConvertCanvas(strings.NewReader(testCanvasHTML))
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Project spec\n\n"+
+		"The **goal** is to _ship_ [the thing](https://example.com).\n\n"+
+		"- First\n    - Nested\n- Second\n\n"+
+		"3. Third\n4. Fourth\n\n"+
+		"- [x] Done\n- [ ] Todo\n\n"+
+		"```\ngo build\ngo test\n```\n\n"+
+		"> Quoted `code`\n\n"+
+		"| Name | Owner |\n| --- | --- |\n| API | a\\|b |", markdown)
+}
+
+func TestTransformPostsCanvases(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	zipWriter := zip.NewWriter(buf)
+	for _, id := range []string{"F1", "F2", "F3"} {
+		w, err := zipWriter.Create("__uploads/" + id + "/canvas.html")
+		require.NoError(t, err)
+		_, err = w.Write([]byte(`<h1>Canvas ` + id + `</h1><p>Content</p>`))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	slackTransformer := NewTransformer("test", log.New())
+	slackExport, err := slackTransformer.ParseSlackExportFile(zipReader, true)
+	require.NoError(t, err)
+	slackExport.Users = []SlackUser{{Id: "U1", Username: "alice"}, {Id: "U2", Username: "bob"}}
+	slackExport.PublicChannels = []SlackChannel{{Id: "C1", Name: "project", Members: []string{"U1", "U2"}, Type: model.ChannelTypeOpen}}
+	slackExport.Posts = map[string][]SlackPost{
+		"project": {
+			{
+				User:      "U1",
+				Type:      "message",
+				TimeStamp: "1600000000.000100",
+				Files:     []*SlackFile{{Id: "F1", Name: "canvas.html", Title: "Spec", Filetype: "quip", User: "U1"}},
+			},
+			{
+				User:      "U2",
+				Type:      "message",
+				Text:      "Have a look at the plan",
+				TimeStamp: "1600000001.000100",
+				Files:     []*SlackFile{{Id: "F2", Name: "canvas.html", Title: "Plan", Filetype: "quip", User: "U1"}},
+			},
+			{
+				User:      "U2",
+				Type:      "message",
+				TimeStamp: "1600000002.000100",
+				ThreadTS:  "1600000001.000100",
+				Files:     []*SlackFile{{Id: "F3", Name: "canvas.html", Title: "Notes", Filetype: "quip", User: "U2"}},
+			},
+		},
+	}
+
+	require.NoError(t, slackTransformer.Transform(slackExport, t.TempDir(), false, false, false, false, false))
+
+	posts := map[string]*IntermediatePost{}
+	for _, post := range slackTransformer.Intermediate.Posts {
+		posts[post.Message] = post
+	}
+	require.Len(t, posts, 4)
+
+	// the post only shares the canvas, so it becomes the canvas post
+	spec := posts["#### Spec\n\n# Canvas F1\n\nContent"]
+	require.NotNil(t, spec)
+	assert.Equal(t, "alice", spec.User)
+	assert.True(t, spec.IsPinned)
+	assert.Equal(t, []string{"bulk-export-attachments/F1/canvas.html"}, spec.Attachments)
+
+	share := posts["Have a look at the plan"]
+	require.NotNil(t, share)
+	assert.False(t, share.IsPinned)
+	assert.Empty(t, share.Attachments)
+
+	plan := posts["#### Plan\n\n# Canvas F2\n\nContent"]
+	require.NotNil(t, plan)
+	assert.Equal(t, "alice", plan.User)
+	assert.True(t, plan.IsPinned)
+	assert.Equal(t, []string{"bulk-export-attachments/F2/canvas.html"}, plan.Attachments)
+	assert.Greater(t, plan.CreateAt, share.CreateAt)
+
+	// the reply that only shares a canvas doesn't pin the thread
+	require.Len(t, share.Replies, 1)
+	assert.Empty(t, share.Replies[0].Message)
+	notes := posts["#### Notes\n\n# Canvas F3\n\nContent"]
+	require.NotNil(t, notes)
+	assert.Equal(t, "bob", notes.User)
+	assert.True(t, notes.IsPinned)
+	assert.Equal(t, []string{"bulk-export-attachments/F3/canvas.html"}, notes.Attachments)
+}
+
+func TestTransformChannelCanvases(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	zipWriter := zip.NewWriter(buf)
+	for _, id := range []string{"F1", "F2"} {
+		w, err := zipWriter.Create("__uploads/" + id + "/canvas.html")
+		require.NoError(t, err)
+		_, err = w.Write([]byte(`<h1>Canvas ` + id + `</h1>`))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	slackTransformer := NewTransformer("test", log.New())
+	slackExport, err := slackTransformer.ParseSlackExportFile(zipReader, true)
+	require.NoError(t, err)
+	slackExport.Users = []SlackUser{{Id: "U1", Username: "alice"}, {Id: "U2", Username: "bob"}}
+	slackExport.PublicChannels = []SlackChannel{
+		{Id: "C1", Name: "project", Creator: "U2", Members: []string{"U1", "U2"}, Created: 1600000000, Type: model.ChannelTypeOpen,
+			Properties: SlackChannelProperties{Canvas: &SlackChannelCanvas{FileId: "F1"}}},
+		{Id: "C2", Name: "design", Creator: "U1", Members: []string{"U1"}, Type: model.ChannelTypeOpen,
+			Properties: SlackChannelProperties{Canvas: &SlackChannelCanvas{FileId: "F2"}}},
+		{Id: "C3", Name: "sales", Creator: "U1", Members: []string{"U1"}, Type: model.ChannelTypeOpen,
+			Properties: SlackChannelProperties{Canvas: &SlackChannelCanvas{FileId: "F3"}}},
+	}
+	slackExport.Channels = slackExport.PublicChannels
+	slackExport.Posts = map[string][]SlackPost{
+		"design": {
+			{
+				User:      "U1",
+				Type:      "message",
+				TimeStamp: "1600000000.000100",
+				Files:     []*SlackFile{{Id: "F2", Name: "canvas.html", Title: "Design", Filetype: "quip", User: "U1"}},
+			},
+		},
+	}
+
+	require.NoError(t, slackTransformer.Transform(slackExport, t.TempDir(), false, false, false, false, false))
+
+	// the canvas of design is shared in a message, and the one of sales is
+	// not part of the export
+	require.Len(t, slackTransformer.Intermediate.Posts, 2)
+	var canvasPost *IntermediatePost
+	for _, post := range slackTransformer.Intermediate.Posts {
+		if post.Channel == "project" {
+			canvasPost = post
+		}
+	}
+	require.NotNil(t, canvasPost)
+	assert.Equal(t, "#### Channel canvas\n\n# Canvas F1", canvasPost.Message)
+	assert.Equal(t, "bob", canvasPost.User)
+	assert.Equal(t, int64(1600000000000), canvasPost.CreateAt)
+	assert.True(t, canvasPost.IsPinned)
+	assert.Equal(t, []string{"bulk-export-attachments/F1/canvas.html"}, canvasPost.Attachments)
+}
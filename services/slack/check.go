@@ -11,6 +11,13 @@ func getDirectChannelNameFromMembers(members []string) string {
 }
 
 func (t *Transformer) CheckIntermediate() {
+	if len(t.workspaceTransformers) > 0 {
+		for _, wt := range t.workspaceTransformers {
+			wt.CheckIntermediate()
+		}
+		return
+	}
+
 	t.Logger.Info("Checking intermediate resources")
 
 	// create channels index
@@ -79,4 +86,12 @@ func (t *Transformer) CheckIntermediate() {
 			t.Logger.Warnf("-- Channel %s has %d posts but not a channel", channelName, len(posts))
 		}
 	}
+
+	archivedChannels := t.getArchivedChannels()
+	for _, channel := range archivedChannels {
+		t.Logger.Infof("-- Channel %s is archived in Slack and should be archived after the import", channel.Name)
+	}
+	if len(archivedChannels) > 0 {
+		t.Logger.Infof("Found %d archived channels", len(archivedChannels))
+	}
 }
@@ -24,7 +24,8 @@ var ErrOverlapNotEqual = errors.New("download: the downloaded file doesn't match
 //
 // When the check fails, the function returns an error and doesn't silently re-download
 // the whole file. If the server doesn't support resumable downloads, the existing file will
-// be truncated and re-downloaded.
+// be truncated and re-downloaded. A negative size means that the size is unknown, in which
+// case the file is always downloaded from scratch.
 func downloadInto(filename, url string, size int64) error {
 	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0660)
 	if err != nil {
@@ -140,6 +140,16 @@ func TestDownload(t *testing.T) {
 		require.Equal(t, mockData, tempFile)
 	})
 
+	t.Run("successful download, unknown size", func(t *testing.T) {
+		fileName := filepath.Join(os.TempDir(), "download-test")
+		defer os.Remove(fileName)
+		require.NoError(t, os.WriteFile(fileName, mockData[:1024*512], 0660))
+
+		require.NoError(t, downloadInto(fileName, srv.URL+"/resume", -1))
+		tempFile, _ := os.ReadFile(fileName)
+		require.Equal(t, mockData, tempFile)
+	})
+
 	t.Run("unknown file", func(t *testing.T) {
 		fileName := filepath.Join(os.TempDir(), "download-test")
 		defer os.Remove(fileName)
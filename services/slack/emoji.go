@@ -0,0 +1,253 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+const emojiInternal = "emoji"
+
+const slackEmojiAliasPrefix = "alias:"
+
+type IntermediateEmoji struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// source is the URL or the local path of the original image
+	source string
+}
+
+// slackEmojiList is the shape of the response of Slack's emoji.list method.
+type slackEmojiList struct {
+	Emoji map[string]string `json:"emoji"`
+}
+
+func parseCustomEmojiFile(emojiFile string) (map[string]string, error) {
+	data, err := os.ReadFile(emojiFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var emojiList slackEmojiList
+	if err := json.Unmarshal(data, &emojiList); err == nil && len(emojiList.Emoji) > 0 {
+		return emojiList.Emoji, nil
+	}
+
+	// a plain name to URL map
+	var emojis map[string]string
+	if err := json.Unmarshal(data, &emojis); err != nil {
+		return nil, errors.Wrap(err, "custom emoji file must contain a name to URL map")
+	}
+	return emojis, nil
+}
+
+func parseCustomEmojiDirectory(emojiDir string) (map[string]string, error) {
+	entries, err := os.ReadDir(emojiDir)
+	if err != nil {
+		return nil, err
+	}
+
+	emojis := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		emojis[name] = filepath.Join(emojiDir, entry.Name())
+	}
+	return emojis, nil
+}
+
+// ParseCustomEmoji reads the custom emoji of the Slack workspace from either a
+// JSON file mapping emoji names to image URLs, as returned by Slack's
+// emoji.list method, or a directory of images named after the emoji.
+func (t *Transformer) ParseCustomEmoji(emojiSource string) error {
+	t.Intermediate.Emojis = map[string]*IntermediateEmoji{}
+	t.Intermediate.EmojiAliases = map[string]string{}
+	if emojiSource == "" {
+		return nil
+	}
+	t.Logger.Info("Parsing custom emoji")
+
+	info, err := os.Stat(emojiSource)
+	if err != nil {
+		return err
+	}
+
+	var emojis map[string]string
+	if info.IsDir() {
+		emojis, err = parseCustomEmojiDirectory(emojiSource)
+	} else {
+		emojis, err = parseCustomEmojiFile(emojiSource)
+	}
+	if err != nil {
+		t.Logger.Error(err.Error())
+		return err
+	}
+
+	names := make([]string, 0, len(emojis))
+	for name := range emojis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		source := emojis[name]
+		if strings.HasPrefix(source, slackEmojiAliasPrefix) {
+			t.Intermediate.EmojiAliases[name] = strings.TrimPrefix(source, slackEmojiAliasPrefix)
+			continue
+		}
+		if appErr := model.IsValidEmojiName(name); appErr != nil {
+			t.Logger.Warnf("Skipping custom emoji %s as its name is not valid in Mattermost", name)
+			continue
+		}
+		if supportedEmojis[name] {
+			t.Logger.Warnf("Skipping custom emoji %s as its name is used by a system emoji", name)
+			continue
+		}
+		t.Intermediate.Emojis[name] = &IntermediateEmoji{
+			Name:   name,
+			source: source,
+		}
+	}
+
+	// aliases may point to other aliases
+	for alias := range t.Intermediate.EmojiAliases {
+		target := t.Intermediate.EmojiAliases[alias]
+		for i := 0; i < len(emojis); i++ {
+			next, ok := t.Intermediate.EmojiAliases[target]
+			if !ok {
+				break
+			}
+			target = next
+		}
+		t.Intermediate.EmojiAliases[alias] = target
+	}
+
+	t.Logger.Infof("Parsed %d custom emoji and %d aliases", len(t.Intermediate.Emojis), len(t.Intermediate.EmojiAliases))
+	return nil
+}
+
+func copyEmojiImage(source, fullFilePath string) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open emoji image %s", source)
+	}
+	defer sourceFile.Close()
+
+	destFile, err := os.Create(fullFilePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create emoji image %s", fullFilePath)
+	}
+	defer destFile.Close()
+
+	if _, err = io.Copy(destFile, sourceFile); err != nil {
+		return errors.Wrapf(err, "failed to copy emoji image %s", source)
+	}
+	return nil
+}
+
+// dropEmojis drops the custom emoji when attachments are skipped, as they
+// can't be imported without their images. They are then handled as any
+// other unknown emoji.
+func (t *Transformer) dropEmojis() {
+	if len(t.Intermediate.Emojis) == 0 {
+		return
+	}
+	t.Logger.Warnf("Skipping %d custom emoji as attachments are skipped", len(t.Intermediate.Emojis))
+	t.Intermediate.Emojis = map[string]*IntermediateEmoji{}
+}
+
+// TransformEmojis copies the images of the custom emoji into the attachments
+// directory, downloading them if allowed. Emoji without an image are dropped.
+func (t *Transformer) TransformEmojis(attachmentsDir string, allowDownload bool) {
+	if len(t.Intermediate.Emojis) == 0 {
+		return
+	}
+	t.Logger.Info("Transforming custom emoji")
+
+	for name, emoji := range t.Intermediate.Emojis {
+		// emoji shared by several transformers are only transformed once
+		if emoji.Image != "" {
+			continue
+		}
+
+		isRemote := strings.HasPrefix(emoji.source, "http://") || strings.HasPrefix(emoji.source, "https://")
+		if isRemote && !allowDownload {
+			t.Logger.Warnf("Skipping custom emoji %s as downloads are not allowed", emoji.Name)
+			delete(t.Intermediate.Emojis, name)
+			continue
+		}
+
+		fileName := filepath.Base(emoji.source)
+		if isRemote {
+			u, err := url.Parse(emoji.source)
+			if err != nil {
+				t.Logger.WithError(err).Warnf("Skipping custom emoji %s as its URL is not valid", emoji.Name)
+				delete(t.Intermediate.Emojis, name)
+				continue
+			}
+			fileName = path.Base(u.Path)
+		}
+		destFilePath := path.Join(attachmentsInternal, emojiInternal, emoji.Name+path.Ext(fileName))
+		fullFilePath := path.Join(attachmentsDir, destFilePath)
+		if err := createDirectoryForFile(fullFilePath); err != nil {
+			t.Logger.WithError(err).Warnf("Skipping custom emoji %s", emoji.Name)
+			delete(t.Intermediate.Emojis, name)
+			continue
+		}
+
+		var err error
+		if isRemote {
+			// the size of emoji images is not known beforehand
+			err = downloadInto(fullFilePath, emoji.source, -1)
+		} else {
+			err = copyEmojiImage(emoji.source, fullFilePath)
+		}
+		if err != nil {
+			t.Logger.WithError(err).Warnf("Skipping custom emoji %s as its image couldn't be retrieved", emoji.Name)
+			delete(t.Intermediate.Emojis, name)
+			continue
+		}
+
+		emoji.Image = destFilePath
+	}
+}
+
+// emojiRegexp matches the emoji of a message, such as :party-parrot:,
+// capturing their name.
+var emojiRegexp = regexp.MustCompile(`:([a-z0-9_+'-]+):`)
+
+// convertEmojiAliases replaces the custom emoji aliases used in the messages,
+// including the emoji of their rich text blocks, with the emoji they point to,
+// as Mattermost has no emoji aliases.
+func (t *Transformer) convertEmojiAliases() {
+	if len(t.Intermediate.EmojiAliases) == 0 {
+		return
+	}
+
+	convert := func(post *IntermediatePost) {
+		post.Message = emojiRegexp.ReplaceAllStringFunc(post.Message, func(emoji string) string {
+			if target, ok := t.Intermediate.EmojiAliases[emojiRegexp.FindStringSubmatch(emoji)[1]]; ok {
+				return ":" + target + ":"
+			}
+			return emoji
+		})
+	}
+
+	for _, post := range t.Intermediate.Posts {
+		convert(post)
+		for _, reply := range post.Replies {
+			convert(reply)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package slack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCustomEmoji(t *testing.T) {
+	t.Run("From an emoji.list response", func(t *testing.T) {
+		emojiFile := filepath.Join(t.TempDir(), "emoji.json")
+		require.NoError(t, os.WriteFile(emojiFile, []byte(`{
+			"ok": true,
+			"emoji": {
+				"party-parrot": "https://emoji.slack-edge.com/T1/party-parrot/abc.gif",
+				"parrot": "alias:party-parrot",
+				"parrot2": "alias:parrot",
+				"thumbsup-alias": "alias:+1",
+				"Invalid Name": "https://emoji.slack-edge.com/T1/invalid/abc.png"
+			}
+		}`), 0600))
+
+		slackTransformer := NewTransformer("test", log.New())
+		require.NoError(t, slackTransformer.ParseCustomEmoji(emojiFile))
+
+		require.Len(t, slackTransformer.Intermediate.Emojis, 1)
+		assert.Contains(t, slackTransformer.Intermediate.Emojis, "party-parrot")
+		assert.Equal(t, map[string]string{"parrot": "party-parrot", "parrot2": "party-parrot", "thumbsup-alias": "+1"}, slackTransformer.Intermediate.EmojiAliases)
+
+		assert.Equal(t, "party-parrot", slackTransformer.SlackConvertEmojiName("party-parrot"))
+		assert.Equal(t, "party-parrot", slackTransformer.SlackConvertEmojiName("parrot2"))
+		assert.Equal(t, "+1", slackTransformer.SlackConvertEmojiName("thumbsup-alias"))
+
+		// downloads are not allowed, so the emoji can't be imported
+		slackTransformer.TransformEmojis(t.TempDir(), false)
+		assert.Empty(t, slackTransformer.Intermediate.Emojis)
+	})
+
+	t.Run("From a directory of images", func(t *testing.T) {
+		emojiDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(emojiDir, "shipit.png"), []byte("image data"), 0600))
+
+		slackTransformer := NewTransformer("test", log.New())
+		require.NoError(t, slackTransformer.ParseCustomEmoji(emojiDir))
+		require.Len(t, slackTransformer.Intermediate.Emojis, 1)
+
+		attachmentsDir := t.TempDir()
+		slackTransformer.TransformEmojis(attachmentsDir, false)
+		require.Contains(t, slackTransformer.Intermediate.Emojis, "shipit")
+		assert.Equal(t, "bulk-export-attachments/emoji/shipit.png", slackTransformer.Intermediate.Emojis["shipit"].Image)
+		data, err := os.ReadFile(filepath.Join(attachmentsDir, "bulk-export-attachments/emoji/shipit.png"))
+		require.NoError(t, err)
+		assert.Equal(t, "image data", string(data))
+
+		var b strings.Builder
+		require.NoError(t, slackTransformer.ExportEmojis(&b))
+		assert.JSONEq(t, `{"type": "emoji", "emoji": {"name": "shipit", "image": "bulk-export-attachments/emoji/shipit.png"}}`, b.String())
+	})
+}
+
+func TestTransformSkipAttachmentsDropsEmojis(t *testing.T) {
+	emojiDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(emojiDir, "shipit.png"), []byte("image data"), 0600))
+
+	slackTransformer := NewTransformer("test", log.New())
+	require.NoError(t, slackTransformer.ParseCustomEmoji(emojiDir))
+	require.NoError(t, slackTransformer.Transform(&SlackExport{}, "", true, false, false, false, false))
+
+	assert.Empty(t, slackTransformer.Intermediate.Emojis)
+	var b strings.Builder
+	require.NoError(t, slackTransformer.ExportEmojis(&b))
+	assert.Empty(t, b.String())
+}
+
+func TestConvertEmojiAliases(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.Intermediate.EmojiAliases = map[string]string{"parrot": "party-parrot", "thumbsup-alias": "+1"}
+	posts := map[string][]SlackPost{
+		"general": {
+			{Type: "message", User: "U1", Text: "Shipped :parrot::parrot: :thumbsup-alias: :smile:", TimeStamp: "1600000000.000100"},
+			{Type: "message", User: "U2", Text: "Agreed :parrot:", TimeStamp: "1600000001.000100", ThreadTS: "1600000000.000100"},
+		},
+	}
+	result := transformTestPosts(t, slackTransformer, posts)
+	require.Len(t, result, 1)
+	assert.Equal(t, "Shipped :party-parrot::party-parrot: :+1: :smile:", result[0].Message)
+	require.Len(t, result[0].Replies, 1)
+	assert.Equal(t, "Agreed :party-parrot:", result[0].Replies[0].Message)
+}
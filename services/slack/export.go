@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -106,36 +107,77 @@ func GetImportLineFromDirectChannel(team string, channel *IntermediateChannel) *
 	}
 }
 
+func stringOrDefault(s, defaultValue string) string {
+	if s == "" {
+		return defaultValue
+	}
+	return s
+}
+
 func GetImportLineFromUser(user *IntermediateUser, team string) *imports.LineImportData {
 	channelMemberships := []imports.UserChannelImportData{}
+	channelRoles := stringOrDefault(user.ChannelRoles, model.ChannelUserRoleId)
 	for _, channelName := range user.Memberships {
+		roles := channelRoles
+		// guests can't be channel admins
+		if channelRoles != model.ChannelGuestRoleId && containsString(user.AdminMemberships, channelName) {
+			roles = joinRoles(model.ChannelAdminRoleId, channelRoles)
+		}
 		channelMemberships = append(channelMemberships, imports.UserChannelImportData{
 			Name:  model.NewString(channelName),
-			Roles: model.NewString(model.ChannelUserRoleId),
+			Roles: model.NewString(roles),
 		})
 	}
 
 	return &imports.LineImportData{
 		Type: "user",
 		User: &imports.UserImportData{
-			Username:  model.NewString(user.Username),
-			Email:     model.NewString(user.Email),
-			Nickname:  model.NewString(""),
-			FirstName: model.NewString(user.FirstName),
-			LastName:  model.NewString(user.LastName),
-			Position:  model.NewString(user.Position),
-			Roles:     model.NewString(model.SystemUserRoleId),
+			ProfileImage: nonEmptyString(user.ProfileImage),
+			Username:     model.NewString(user.Username),
+			Email:        model.NewString(user.Email),
+			Nickname:     model.NewString(user.Nickname),
+			FirstName:    model.NewString(user.FirstName),
+			LastName:     model.NewString(user.LastName),
+			Position:     model.NewString(user.Position),
+			Locale:       nonEmptyString(user.Locale),
+			Roles:        model.NewString(stringOrDefault(user.Roles, model.SystemUserRoleId)),
+			DeleteAt:     nonZeroInt64(user.DeleteAt),
 			Teams: &[]imports.UserTeamImportData{
 				{
 					Name:     model.NewString(team),
 					Channels: &channelMemberships,
-					Roles:    model.NewString(model.TeamUserRoleId),
+					Roles:    model.NewString(stringOrDefault(user.TeamRoles, model.TeamUserRoleId)),
 				},
 			},
 		},
 	}
 }
 
+// nonZeroInt64 returns a pointer to the given value, or nil if it is zero, so
+// that unset timestamps are left out of the import line.
+func nonZeroInt64(i int64) *int64 {
+	if i == 0 {
+		return nil
+	}
+	return &i
+}
+
+// nonEmptyString returns a pointer to the given string, or nil if it is empty.
+func nonEmptyString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// nonEmptyStrings returns a pointer to the given slice, or nil if it is empty.
+func nonEmptyStrings(s []string) *[]string {
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
+
 func GetAttachmentImportDataFromPaths(paths []string) []imports.AttachmentImportData {
 	attachments := []imports.AttachmentImportData{}
 	for _, path := range paths {
@@ -200,13 +242,21 @@ func GetImportLineFromPost(post *IntermediatePost, team string) *imports.LineImp
 
 		newReply := imports.ReplyImportData{
 			User:        &reply.User,
+			Type:        nonEmptyString(reply.Type),
 			Message:     &reply.Message,
 			CreateAt:    &reply.CreateAt,
+			EditAt:      nonZeroInt64(reply.EditAt),
+			FlaggedBy:   nonEmptyStrings(reply.FlaggedBy),
 			Attachments: &replyAttachments,
 		}
 		replies = append(replies, newReply)
 	}
 
+	var isPinned *bool
+	if post.IsPinned {
+		isPinned = model.NewBool(true)
+	}
+
 	var newPost *imports.LineImportData
 	if post.IsDirect {
 		newPost = &imports.LineImportData{
@@ -214,12 +264,16 @@ func GetImportLineFromPost(post *IntermediatePost, team string) *imports.LineImp
 			DirectPost: &imports.DirectPostImportData{
 				ChannelMembers: &post.ChannelMembers,
 				User:           &post.User,
+				Type:           nonEmptyString(post.Type),
 				Message:        &post.Message,
 				Props:          &post.Props,
 				CreateAt:       &post.CreateAt,
+				EditAt:         nonZeroInt64(post.EditAt),
+				FlaggedBy:      nonEmptyStrings(post.FlaggedBy),
 				Reactions:      post.Reactions,
 				Replies:        &replies,
 				Attachments:    &postAttachments,
+				IsPinned:       isPinned,
 			},
 		}
 	} else {
@@ -229,12 +283,16 @@ func GetImportLineFromPost(post *IntermediatePost, team string) *imports.LineImp
 				Team:        model.NewString(team),
 				Channel:     &post.Channel,
 				User:        &post.User,
+				Type:        nonEmptyString(post.Type),
 				Message:     &post.Message,
 				Props:       &post.Props,
 				CreateAt:    &post.CreateAt,
+				EditAt:      nonZeroInt64(post.EditAt),
+				FlaggedBy:   nonEmptyStrings(post.FlaggedBy),
 				Reactions:   post.Reactions,
 				Replies:     &replies,
 				Attachments: &postAttachments,
+				IsPinned:    isPinned,
 			},
 		}
 	}
@@ -289,7 +347,9 @@ func (t *Transformer) ExportDirectChannels(channels []*IntermediateChannel, writ
 	return nil
 }
 
-func (t *Transformer) ExportUsers(writer io.Writer) error {
+// getMergedUsers returns the users to export, merging the users that share a
+// username.
+func (t *Transformer) getMergedUsers() ([]*IntermediateUser, error) {
 	users := []*IntermediateUser{}
 	for _, user := range t.Intermediate.UsersById {
 		users = append(users, user)
@@ -302,6 +362,14 @@ func (t *Transformer) ExportUsers(writer io.Writer) error {
 			t.Logger.Warnf("Merging users with id %s and %s because they share the username %s", a.Id, b.Id, a.Username)
 			return mergeIntermediateUsers(a, b)
 		})
+	if err != nil {
+		return nil, err
+	}
+	return mergedUsers, nil
+}
+
+func (t *Transformer) ExportUsers(writer io.Writer) error {
+	mergedUsers, err := t.getMergedUsers()
 	if err != nil {
 		return err
 	}
@@ -323,6 +391,9 @@ func mergeIntermediateUsers(a, b *IntermediateUser) (*IntermediateUser, error) {
 	if a.Username != b.Username {
 		return nil, errors.Errorf("cannot merge users with different usernames: %s and %s", a.Username, b.Username)
 	}
+	if a.Nickname != b.Nickname {
+		return nil, errors.Errorf("cannot merge users with different nicknames: %s and %s", a.Nickname, b.Nickname)
+	}
 	if a.FirstName != b.FirstName {
 		return nil, errors.Errorf("cannot merge users with different first names: %s and %s", a.FirstName, b.FirstName)
 	}
@@ -335,17 +406,70 @@ func mergeIntermediateUsers(a, b *IntermediateUser) (*IntermediateUser, error) {
 	if a.Email != b.Email {
 		return nil, errors.Errorf("cannot merge users with different emails: %s and %s", a.Email, b.Email)
 	}
+	if a.Roles != b.Roles || a.TeamRoles != b.TeamRoles || a.ChannelRoles != b.ChannelRoles {
+		return nil, errors.Errorf("cannot merge users with different roles: %s and %s", a.Username, b.Username)
+	}
 	if a.Password == "" {
 		newUser.Password = b.Password
 	}
+	if a.Locale == "" {
+		newUser.Locale = b.Locale
+	}
+	if a.Timezone == "" {
+		newUser.Timezone = b.Timezone
+	}
+	if a.HomeTeam == "" {
+		newUser.HomeTeam = b.HomeTeam
+	}
+	// the merged user is only deactivated if both users are
+	if a.DeleteAt == 0 || b.DeleteAt == 0 {
+		newUser.DeleteAt = 0
+	} else if b.DeleteAt > a.DeleteAt {
+		newUser.DeleteAt = b.DeleteAt
+	}
 	mergedMemberships, err := mergeSlicesWith(a.Memberships, b.Memberships, func(x string) string { return x }, func(x, y string) (string, error) { return x, nil })
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot merge memberships")
 	}
 	newUser.Memberships = mergedMemberships
+	mergedAdminMemberships, err := mergeSlicesWith(a.AdminMemberships, b.AdminMemberships, func(x string) string { return x }, func(x, y string) (string, error) { return x, nil })
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot merge admin memberships")
+	}
+	newUser.AdminMemberships = mergedAdminMemberships
 	return &newUser, nil
 }
 
+func GetImportLineFromEmoji(emoji *IntermediateEmoji) *imports.LineImportData {
+	return &imports.LineImportData{
+		Type: "emoji",
+		Emoji: &imports.EmojiImportData{
+			Name:  model.NewString(emoji.Name),
+			Image: model.NewString(emoji.Image),
+		},
+	}
+}
+
+func (t *Transformer) ExportEmojis(writer io.Writer) error {
+	names := []string{}
+	for name, emoji := range t.Intermediate.Emojis {
+		// emoji without an image were not transformed
+		if emoji.Image != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		line := GetImportLineFromEmoji(t.Intermediate.Emojis[name])
+		if err := ExportWriteLine(writer, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (t *Transformer) ExportPosts(writer io.Writer) error {
 	for _, post := range t.Intermediate.Posts {
 		line := GetImportLineFromPost(post, t.TeamName)
@@ -363,6 +487,10 @@ func (t *Transformer) Export(outputFilePath string) error {
 	}
 	defer outputFile.Close()
 
+	if len(t.workspaceTransformers) > 0 {
+		return t.exportGrid(outputFile)
+	}
+
 	t.Logger.Info("Exporting version")
 	if err := t.ExportVersion(outputFile); err != nil {
 		return err
@@ -393,10 +521,73 @@ func (t *Transformer) Export(outputFilePath string) error {
 		return err
 	}
 
+	t.Logger.Info("Exporting custom emoji")
+	if err := t.ExportEmojis(outputFile); err != nil {
+		return err
+	}
+
 	t.Logger.Info("Exporting posts")
 	if err := t.ExportPosts(outputFile); err != nil {
 		return err
 	}
 
+	return t.exportReports()
+}
+
+// writeReport writes a report into the file at the path, or at the default
+// path if it is empty.
+func (t *Transformer) writeReport(description, reportFilePath, defaultPath string, write func(io.Writer) error) error {
+	if reportFilePath == "" {
+		reportFilePath = defaultPath
+	}
+
+	reportFile, err := os.Create(reportFilePath)
+	if err != nil {
+		return err
+	}
+	defer reportFile.Close()
+
+	t.Logger.Infof("Exporting %s to %s", description, reportFilePath)
+	return write(reportFile)
+}
+
+// exportReports writes the reports that accompany the import file.
+func (t *Transformer) exportReports() error {
+	if t.ArchivedChannels == ArchivedChannelsSeparateReport {
+		if err := t.ExportArchivedChannelsReport(t.ArchivedChannelsReportPath); err != nil {
+			return err
+		}
+	}
+
+	if len(t.Intermediate.ExternalFiles) > 0 {
+		if err := t.ExportExternalFilesReport(t.ExternalFilesReportPath); err != nil {
+			return err
+		}
+	}
+
+	if len(t.Intermediate.UnavailableContent) > 0 {
+		if err := t.ExportUnavailableContentReport(t.UnavailableContentReportPath); err != nil {
+			return err
+		}
+	}
+
+	if len(t.getExternalUsers()) > 0 {
+		if err := t.ExportExternalUsersReport(t.ExternalUsersReportPath); err != nil {
+			return err
+		}
+	}
+
+	if len(t.Intermediate.Usergroups) > 0 {
+		if err := t.ExportUsergroupsDefinition(t.UsergroupsDefinitionPath); err != nil {
+			return err
+		}
+	}
+
+	if t.TimezonesScriptPath != "" {
+		if err := t.ExportTimezonesScriptFile(t.TimezonesScriptPath); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
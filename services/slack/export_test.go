@@ -1,6 +1,7 @@
 package slack
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -137,3 +138,50 @@ func TestSlackConvertTimeStampToMicroSeconds(t *testing.T) {
 		})
 	}
 }
+
+func TestGetImportLineFromPostEditAt(t *testing.T) {
+	t.Run("Edited posts and replies should carry their edit time", func(t *testing.T) {
+		post := &IntermediatePost{
+			User:     "u1",
+			Channel:  "c1",
+			Message:  "edited root",
+			CreateAt: 1549307811071,
+			EditAt:   1549307899000,
+			Replies: []*IntermediatePost{
+				{User: "u2", Message: "edited reply", CreateAt: 1549307812000, EditAt: 1549307813000},
+			},
+		}
+
+		line := GetImportLineFromPost(post, "team")
+		require.NotNil(t, line.Post)
+		require.NotNil(t, line.Post.EditAt)
+		require.Equal(t, int64(1549307899000), *line.Post.EditAt)
+		require.Len(t, *line.Post.Replies, 1)
+		require.NotNil(t, (*line.Post.Replies)[0].EditAt)
+		require.Equal(t, int64(1549307813000), *(*line.Post.Replies)[0].EditAt)
+	})
+
+	t.Run("Posts that were never edited should not have an edit time", func(t *testing.T) {
+		post := &IntermediatePost{
+			User:     "u1",
+			Message:  "pristine",
+			CreateAt: 1549307811071,
+			IsDirect: true,
+		}
+
+		line := GetImportLineFromPost(post, "team")
+		require.NotNil(t, line.DirectPost)
+		require.Nil(t, line.DirectPost.EditAt)
+	})
+}
+
+func TestSlackPostEditAt(t *testing.T) {
+	posts, err := SlackParsePosts(strings.NewReader(`[
+		{"type": "message", "ts": "1549307811.071000", "text": "a"},
+		{"type": "message", "ts": "1549307811.071000", "text": "b", "edited": {"user": "U1", "ts": "1549307899.000000"}}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	require.Equal(t, int64(0), posts[0].EditAt())
+	require.Equal(t, int64(1549307899000), posts[1].EditAt())
+}
@@ -0,0 +1,115 @@
+package slack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const DefaultExternalFilesReportPath = "external-files.json"
+
+// externalFileServices holds the names of the services external files are
+// stored in.
+var externalFileServices = map[string]string{
+	"gdrive":   "Google Drive",
+	"dropbox":  "Dropbox",
+	"box":      "Box",
+	"onedrive": "OneDrive",
+}
+
+// ExternalFile is a file shared in Slack from another service, which has to
+// be shared again in Mattermost for the link to it to be useful.
+type ExternalFile struct {
+	Team     string `json:"team"`
+	Channel  string `json:"channel"`
+	User     string `json:"user"`
+	CreateAt int64  `json:"create_at"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Service  string `json:"service"`
+}
+
+// isExternalFile returns true if the file is stored outside of Slack, so
+// there is nothing to upload. Hosted files only count when they are neither
+// in the export nor downloadable.
+func isExternalFile(file *SlackFile, uploads map[string]*zip.File) bool {
+	if file.IsExternal || file.Mode == "external" {
+		return true
+	}
+	if file.Mode == "hosted" && file.DownloadURL == "" {
+		_, ok := uploads[file.Id]
+		return !ok
+	}
+	return false
+}
+
+// externalFileLink returns a Markdown link to the file, or an empty string if
+// the file has no URL.
+func externalFileLink(file *SlackFile) string {
+	url := stringOrDefault(file.URLPrivate, file.Permalink)
+	if url == "" {
+		return ""
+	}
+	title := stringOrDefault(file.Title, stringOrDefault(file.Name, url))
+	link := "[" + bookmarkTitleEscaper.Replace(title) + "](" + url + ")"
+	if service, ok := externalFileServices[file.ExternalType]; ok {
+		link += " (" + service + ")"
+	}
+	return link
+}
+
+// addExternalFileToPost appends a link to the external file to the message of
+// the post and records the file for the external files report.
+func (t *Transformer) addExternalFileToPost(file *SlackFile, newPost *IntermediatePost) {
+	link := externalFileLink(file)
+	if link == "" {
+		t.Logger.Warnf("Unable to link external file %s as it has no URL", file.Id)
+		return
+	}
+
+	if newPost.Message == "" {
+		newPost.Message = link
+	} else {
+		newPost.Message += "\n\n" + link
+	}
+
+	t.Intermediate.ExternalFiles = append(t.Intermediate.ExternalFiles, ExternalFile{
+		Team:     t.TeamName,
+		Channel:  newPost.Channel,
+		User:     newPost.User,
+		CreateAt: newPost.CreateAt,
+		Title:    stringOrDefault(file.Title, file.Name),
+		URL:      stringOrDefault(file.URLPrivate, file.Permalink),
+		Service:  stringOrDefault(externalFileServices[file.ExternalType], file.ExternalType),
+	})
+}
+
+// ExportExternalFiles writes the list of files shared from other services,
+// which need to be shared again with the Mattermost users.
+func (t *Transformer) ExportExternalFiles(writer io.Writer) error {
+	externalFiles := append([]ExternalFile{}, t.Intermediate.ExternalFiles...)
+	sort.SliceStable(externalFiles, func(i, j int) bool {
+		if externalFiles[i].Channel != externalFiles[j].Channel {
+			return externalFiles[i].Channel < externalFiles[j].Channel
+		}
+		return externalFiles[i].CreateAt < externalFiles[j].CreateAt
+	})
+
+	b, err := json.MarshalIndent(externalFiles, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "An error occurred marshalling the external files.")
+	}
+
+	if _, err := writer.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "An error occurred writing the external files.")
+	}
+
+	return nil
+}
+
+func (t *Transformer) ExportExternalFilesReport(reportFilePath string) error {
+	return t.writeReport("the list of external files", reportFilePath, DefaultExternalFilesReportPath, t.ExportExternalFiles)
+}
@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsExternalFile(t *testing.T) {
+	uploads := map[string]*zip.File{"F3": {}}
+
+	assert.True(t, isExternalFile(&SlackFile{Id: "F1", IsExternal: true}, uploads))
+	assert.True(t, isExternalFile(&SlackFile{Id: "F1", Mode: "external"}, uploads))
+	assert.True(t, isExternalFile(&SlackFile{Id: "F2", Mode: "hosted"}, uploads))
+	assert.False(t, isExternalFile(&SlackFile{Id: "F2", Mode: "hosted", DownloadURL: "https://files.slack.com/F2"}, uploads))
+	assert.False(t, isExternalFile(&SlackFile{Id: "F3", Mode: "hosted"}, uploads))
+}
+
+func TestExternalFileLink(t *testing.T) {
+	assert.Equal(t, "[Roadmap \\[2024\\]](https://docs.google.com/d/1) (Google Drive)", externalFileLink(&SlackFile{Title: "Roadmap [2024]", ExternalType: "gdrive", URLPrivate: "https://docs.google.com/d/1"}))
+	assert.Equal(t, "[plan.pdf](https://example.com/plan)", externalFileLink(&SlackFile{Name: "plan.pdf", Permalink: "https://example.com/plan"}))
+	assert.Empty(t, externalFileLink(&SlackFile{Title: "No URL"}))
+}
+
+func TestAddFilesToPostExternalFiles(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	post := &SlackPost{Files: []*SlackFile{
+		{Id: "F1", Name: "Roadmap", Title: "Roadmap", IsExternal: true, ExternalType: "gdrive", URLPrivate: "https://docs.google.com/d/1"},
+		{Id: "F2", Name: "Budget", Title: "Budget", IsExternal: true, ExternalType: "dropbox", URLPrivate: "https://dropbox.com/s/2"},
+	}}
+	newPost := &IntermediatePost{User: "alice", Channel: "general", Message: "Plans", CreateAt: 1600000000001}
+
+	slackTransformer.AddFilesToPost(post, true, &SlackExport{}, "", newPost, false)
+
+	assert.Equal(t, "Plans\n\n[Roadmap](https://docs.google.com/d/1) (Google Drive)\n\n[Budget](https://dropbox.com/s/2) (Dropbox)", newPost.Message)
+	assert.Empty(t, newPost.Attachments)
+	require.Len(t, slackTransformer.Intermediate.ExternalFiles, 2)
+
+	var b bytes.Buffer
+	require.NoError(t, slackTransformer.ExportExternalFiles(&b))
+	assert.JSONEq(t, `[
+		{"team": "test", "channel": "general", "user": "alice", "create_at": 1600000000001, "title": "Roadmap", "url": "https://docs.google.com/d/1", "service": "Google Drive"},
+		{"team": "test", "channel": "general", "user": "alice", "create_at": 1600000000001, "title": "Budget", "url": "https://dropbox.com/s/2", "service": "Dropbox"}
+	]`, b.String())
+}
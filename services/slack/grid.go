@@ -0,0 +1,423 @@
+package slack
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/app/imports"
+	"github.com/pkg/errors"
+)
+
+// SlackWorkspaceExport is the part of an Enterprise Grid organisation export
+// that belongs to one of its workspaces.
+type SlackWorkspaceExport struct {
+	// Name is the directory of the workspace in the export. It is empty for
+	// the channels of the organisation itself, such as org-wide channels.
+	Name   string
+	Export *SlackExport
+}
+
+// orgFiles are the files at the root of an Enterprise Grid export that are
+// shared by all of its workspaces.
+var orgFiles = []string{"users.json", "usergroups.json"}
+
+// GridWorkspaceNames returns the directories of the workspaces of an
+// Enterprise Grid export, which have their own channel lists, or none if the
+// export is not one.
+func GridWorkspaceNames(zipReader *zip.Reader) []string {
+	names := []string{}
+	for _, file := range zipReader.File {
+		dir, fileName := path.Split(file.Name)
+		if fileName == "channels.json" && dir != "" && strings.Count(dir, "/") == 1 {
+			names = append(names, strings.TrimSuffix(dir, "/"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsGridExport returns true if the export is of an Enterprise Grid
+// organisation, with a directory for each workspace.
+func IsGridExport(zipReader *zip.Reader) bool {
+	return len(GridWorkspaceNames(zipReader)) > 0
+}
+
+func isOrgFile(name string) bool {
+	return containsString(orgFiles, name) || strings.HasPrefix(name, "__uploads/") || strings.HasPrefix(name, "__avatars/")
+}
+
+// workspaceZipReader returns the files of the workspace as if they were a
+// regular export, along with the files of the organisation it needs. The
+// files of the workspace take precedence, as they are read last.
+func workspaceZipReader(zipReader *zip.Reader, workspace string, workspaces []string) *zip.Reader {
+	orgFiles := []*zip.File{}
+	workspaceFiles := []*zip.File{}
+	for _, file := range zipReader.File {
+		workspaceName, name := "", file.Name
+		if i := strings.Index(file.Name, "/"); i != -1 && containsString(workspaces, file.Name[:i]) {
+			workspaceName, name = file.Name[:i], file.Name[i+1:]
+		}
+
+		switch {
+		case workspaceName == workspace:
+			workspaceFiles = append(workspaceFiles, renamedZipFile(file, name))
+		case workspaceName == "" && isOrgFile(name):
+			orgFiles = append(orgFiles, file)
+		}
+	}
+	return &zip.Reader{File: append(orgFiles, workspaceFiles...)}
+}
+
+func renamedZipFile(file *zip.File, name string) *zip.File {
+	renamed := *file
+	renamed.Name = name
+	return &renamed
+}
+
+// removeSeenChannels removes the channels that were already found in another
+// workspace, along with their posts, as channels shared between workspaces
+// and conversations between users of the organisation are exported with each
+// workspace.
+func removeSeenChannels(slackExport *SlackExport, seen map[string]bool) {
+	filter := func(channels []SlackChannel) []SlackChannel {
+		result := []SlackChannel{}
+		for _, channel := range channels {
+			if channel.Id != "" && seen[channel.Id] {
+				continue
+			}
+			result = append(result, channel)
+		}
+		return result
+	}
+
+	for _, channel := range slackExport.Channels {
+		if channel.Id != "" && seen[channel.Id] {
+			delete(slackExport.Posts, getOriginalName(channel))
+		}
+	}
+	slackExport.Channels = filter(slackExport.Channels)
+	slackExport.PublicChannels = filter(slackExport.PublicChannels)
+	slackExport.PrivateChannels = filter(slackExport.PrivateChannels)
+	slackExport.GroupChannels = filter(slackExport.GroupChannels)
+	slackExport.DirectChannels = filter(slackExport.DirectChannels)
+
+	for _, channel := range slackExport.Channels {
+		seen[channel.Id] = true
+	}
+}
+
+// ParseSlackGridExportFile reads an Enterprise Grid organisation export,
+// returning the export of each workspace. The channels of the organisation
+// itself come first, and channels found in several workspaces are only kept
+// in the first one.
+func (t *Transformer) ParseSlackGridExportFile(zipReader *zip.Reader, skipConvertPosts bool) ([]*SlackWorkspaceExport, error) {
+	names := GridWorkspaceNames(zipReader)
+	if err := t.checkWorkspaceTeams(names); err != nil {
+		return nil, err
+	}
+	seenChannels := map[string]bool{}
+
+	workspaces := []*SlackWorkspaceExport{}
+	for _, name := range append([]string{""}, names...) {
+		if name == "" {
+			t.Logger.Info("Parsing the channels of the organisation")
+		} else {
+			t.Logger.Infof("Parsing workspace %s", name)
+		}
+
+		slackExport, err := t.ParseSlackExportFile(workspaceZipReader(zipReader, name, names), skipConvertPosts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse workspace %q", name)
+		}
+		removeSeenChannels(slackExport, seenChannels)
+		if name == "" && len(slackExport.Channels) == 0 {
+			continue
+		}
+		slackExport.TeamName = t.workspaceTeamName(name)
+
+		workspaces = append(workspaces, &SlackWorkspaceExport{Name: name, Export: slackExport})
+	}
+
+	return workspaces, nil
+}
+
+// workspaceTeamName returns the name of the Mattermost team the workspace is
+// imported into. The organisation's own channels go into the team of the
+// transformer.
+func (t *Transformer) workspaceTeamName(workspace string) string {
+	if workspace == "" {
+		return t.TeamName
+	}
+	return t.WorkspaceTeams[workspace]
+}
+
+// checkWorkspaceTeams returns an error if any of the workspaces has no team
+// to be imported into, as the teams are not created by the import.
+func (t *Transformer) checkWorkspaceTeams(workspaces []string) error {
+	missing := []string{}
+	for _, workspace := range workspaces {
+		if t.WorkspaceTeams[workspace] == "" {
+			missing = append(missing, workspace)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("no Mattermost team given for the workspaces %s of the Enterprise Grid export", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// workspaceReportPath returns the path of a report of the workspace, next to
+// the report of the organisation.
+func workspaceReportPath(reportPath, defaultPath, workspace string) string {
+	if workspace == "" {
+		return reportPath
+	}
+	reportPath = stringOrDefault(reportPath, defaultPath)
+	ext := filepath.Ext(reportPath)
+	return strings.TrimSuffix(reportPath, ext) + "-" + workspace + ext
+}
+
+// newWorkspaceTransformer returns a transformer for the workspace with the
+// same settings, overrides, users and custom emoji. The users and custom emoji
+// belong to the organisation, so they are shared by all the workspaces and
+// exported once. Each workspace gets its own copy of the users, as their
+// channel memberships differ.
+func (t *Transformer) newWorkspaceTransformer(workspace *SlackWorkspaceExport) *Transformer {
+	wt := *t
+	wt.TeamName = workspace.Export.TeamName
+	wt.Logger = t.Logger.WithField("team", wt.TeamName)
+	wt.workspaceTransformers = nil
+	wt.replacedRoots = nil
+	wt.deletedRoots = nil
+	wt.permalinkTargets = nil
+	wt.sharedCanvases = nil
+
+	usersById := map[string]*IntermediateUser{}
+	for id, user := range t.Intermediate.UsersById {
+		workspaceUser := *user
+		usersById[id] = &workspaceUser
+	}
+
+	wt.Intermediate = &Intermediate{
+		UsersById:        usersById,
+		UserOverrides:    t.Intermediate.UserOverrides,
+		ChannelOverrides: t.Intermediate.ChannelOverrides,
+		ChannelAdmins:    t.Intermediate.ChannelAdmins,
+		EmojiAliases:     t.Intermediate.EmojiAliases,
+		Emojis:           t.Intermediate.Emojis,
+	}
+
+	wt.ArchivedChannelsReportPath = workspaceReportPath(t.ArchivedChannelsReportPath, DefaultArchivedChannelsReportPath, workspace.Name)
+	wt.UsergroupsDefinitionPath = workspaceReportPath(t.UsergroupsDefinitionPath, DefaultUsergroupsDefinitionPath, workspace.Name)
+	wt.ExternalUsersReportPath = workspaceReportPath(t.ExternalUsersReportPath, DefaultExternalUsersReportPath, workspace.Name)
+	wt.ExternalFilesReportPath = workspaceReportPath(t.ExternalFilesReportPath, DefaultExternalFilesReportPath, workspace.Name)
+	wt.UnavailableContentReportPath = workspaceReportPath(t.UnavailableContentReportPath, DefaultUnavailableContentReportPath, workspace.Name)
+	wt.TimezonesScriptPath = ""
+
+	return &wt
+}
+
+// gridWorkspaceMembers returns the Slack IDs of the users of the organisation
+// that belong to the workspace. As the export doesn't give the ID of the
+// workspace, it is taken to be the one its channel members most often belong
+// to.
+func gridWorkspaceMembers(workspace *SlackWorkspaceExport) map[string]bool {
+	members := map[string]bool{}
+	if workspace.Name == "" {
+		return members
+	}
+
+	teamsByUser := map[string][]string{}
+	for _, user := range workspace.Export.Users {
+		if user.EnterpriseUser != nil {
+			teamsByUser[user.Id] = user.EnterpriseUser.Teams
+		}
+	}
+	counts := map[string]int{}
+	for _, channel := range workspace.Export.Channels {
+		for _, member := range channel.Members {
+			for _, teamId := range teamsByUser[member] {
+				counts[teamId]++
+			}
+		}
+	}
+	workspaceId := ""
+	for teamId, count := range counts {
+		if count > counts[workspaceId] || count == counts[workspaceId] && teamId < workspaceId {
+			workspaceId = teamId
+		}
+	}
+	if workspaceId == "" {
+		return members
+	}
+
+	for userId, teams := range teamsByUser {
+		if containsString(teams, workspaceId) {
+			members[userId] = true
+		}
+	}
+	return members
+}
+
+// gridOrgUsers returns an export with the users of all the workspaces and
+// their profile images. Besides the users of the organisation, each workspace
+// has the users harvested from its own posts.
+func gridOrgUsers(workspaces []*SlackWorkspaceExport) *SlackExport {
+	orgExport := &SlackExport{ProfileImages: map[string]*zip.File{}}
+	seen := map[string]bool{}
+	for _, workspace := range workspaces {
+		for _, user := range workspace.Export.Users {
+			if !seen[user.Id] {
+				seen[user.Id] = true
+				orgExport.Users = append(orgExport.Users, user)
+			}
+		}
+		for id, zipFile := range workspace.Export.ProfileImages {
+			orgExport.ProfileImages[id] = zipFile
+		}
+	}
+	return orgExport
+}
+
+// TransformGrid transforms each workspace of an Enterprise Grid organisation
+// export into its own Mattermost team. The users of the organisation and their
+// profile images are transformed once, and shared by all the workspaces.
+func (t *Transformer) TransformGrid(workspaces []*SlackWorkspaceExport, attachmentsDir string, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly bool) error {
+	t.workspaceTransformers = []*Transformer{}
+	if len(workspaces) == 0 {
+		return nil
+	}
+
+	orgExport := gridOrgUsers(workspaces)
+	t.TransformUsers(orgExport.Users)
+	if !skipAttachments {
+		t.TransformProfileImages(orgExport, attachmentsDir, allowDownload)
+	}
+
+	for _, workspace := range workspaces {
+		wt := t.newWorkspaceTransformer(workspace)
+		wt.workspaceMembers = gridWorkspaceMembers(workspace)
+		t.Logger.Infof("Transforming workspace %q into team %s", workspace.Name, wt.TeamName)
+		if err := wt.transformTeam(workspace.Export, attachmentsDir, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly); err != nil {
+			return err
+		}
+		t.workspaceTransformers = append(t.workspaceTransformers, wt)
+	}
+	return nil
+}
+
+// belongsToWorkspace returns true if the user is a member of the workspace in
+// Slack, or took part in it as a channel member or as the author of a post.
+func belongsToWorkspace(user *IntermediateUser, members, authors map[string]bool) bool {
+	return members[user.Id] || len(user.Memberships) > 0 || authors[user.Username]
+}
+
+// ExportGridUsers writes the users of all the workspaces once, with a team
+// membership for each workspace they belong to. Users that belong to none
+// are members of the first team, so that their posts can be imported.
+func (t *Transformer) ExportGridUsers(writer io.Writer) error {
+	usernames := []string{}
+	lines := map[string]*imports.LineImportData{}
+	teams := map[string][]imports.UserTeamImportData{}
+
+	for _, wt := range t.workspaceTransformers {
+		authors := map[string]bool{}
+		for _, post := range wt.Intermediate.Posts {
+			authors[post.User] = true
+			for _, reply := range post.Replies {
+				authors[reply.User] = true
+			}
+		}
+
+		users, err := wt.getMergedUsers()
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			line := GetImportLineFromUser(user, wt.TeamName)
+			if _, ok := lines[user.Username]; !ok {
+				usernames = append(usernames, user.Username)
+				lines[user.Username] = line
+			}
+			if belongsToWorkspace(user, wt.workspaceMembers, authors) {
+				teams[user.Username] = append(teams[user.Username], (*line.User.Teams)...)
+			}
+		}
+	}
+
+	for _, username := range usernames {
+		line := lines[username]
+		if userTeams, ok := teams[username]; ok {
+			line.User.Teams = &userTeams
+		}
+		if err := ExportWriteLine(writer, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportGrid writes the import lines of all the workspaces, in the order the
+// import requires.
+func (t *Transformer) exportGrid(writer io.Writer) error {
+	t.Logger.Info("Exporting version")
+	if err := t.ExportVersion(writer); err != nil {
+		return err
+	}
+
+	for _, wt := range t.workspaceTransformers {
+		wt.Logger.Info("Exporting public channels")
+		if err := wt.ExportChannels(wt.Intermediate.PublicChannels, writer); err != nil {
+			return err
+		}
+
+		wt.Logger.Info("Exporting private channels")
+		if err := wt.ExportChannels(wt.Intermediate.PrivateChannels, writer); err != nil {
+			return err
+		}
+	}
+
+	t.Logger.Info("Exporting users")
+	if err := t.ExportGridUsers(writer); err != nil {
+		return err
+	}
+
+	for _, wt := range t.workspaceTransformers {
+		wt.Logger.Info("Exporting group channels")
+		if err := wt.ExportDirectChannels(wt.Intermediate.GroupChannels, writer); err != nil {
+			return err
+		}
+
+		wt.Logger.Info("Exporting direct channels")
+		if err := wt.ExportDirectChannels(wt.Intermediate.DirectChannels, writer); err != nil {
+			return err
+		}
+	}
+
+	t.Logger.Info("Exporting custom emoji")
+	if err := t.ExportEmojis(writer); err != nil {
+		return err
+	}
+
+	for _, wt := range t.workspaceTransformers {
+		wt.Logger.Info("Exporting posts")
+		if err := wt.ExportPosts(writer); err != nil {
+			return err
+		}
+		if err := wt.exportReports(); err != nil {
+			return err
+		}
+	}
+
+	if t.TimezonesScriptPath != "" {
+		if err := t.ExportTimezonesScriptFile(t.TimezonesScriptPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createZipReader(t *testing.T, files map[string]string) *zip.Reader {
+	buf := bytes.NewBuffer(nil)
+	zipWriter := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zipReader
+}
+
+func createGridZipReader(t *testing.T) *zip.Reader {
+	return createZipReader(t, map[string]string{
+		"users.json": `[
+			{"id": "U1", "name": "alice", "team_id": "T1", "enterprise_user": {"enterprise_id": "E1", "teams": ["T1"]}},
+			{"id": "U2", "name": "bob", "team_id": "T2", "enterprise_user": {"enterprise_id": "E1", "teams": ["T2"]}},
+			{"id": "U3", "name": "carol", "team_id": "T2", "enterprise_user": {"enterprise_id": "E1", "teams": ["T1", "T2"]}}
+		]`,
+		"Engineering/channels.json": `[
+			{"id": "C1", "name": "general", "creator": "U1", "members": ["U1", "U3"]},
+			{"id": "C3", "name": "announcements", "creator": "U1", "members": ["U1", "U2", "U3"]}
+		]`,
+		"Engineering/general/2020-09-13.json":       `[{"type": "message", "user": "U1", "text": "Hello engineering", "ts": "1600000000.000100"}]`,
+		"Engineering/announcements/2020-09-13.json": `[{"type": "message", "user": "U1", "text": "Org news", "ts": "1600000001.000100"}]`,
+		"Sales/channels.json": `[
+			{"id": "C2", "name": "general", "creator": "U2", "members": ["U2", "U3"]},
+			{"id": "C3", "name": "announcements", "creator": "U1", "members": ["U1", "U2", "U3"]}
+		]`,
+		"Sales/general/2020-09-13.json":       `[{"type": "message", "user": "U2", "text": "Hello sales", "ts": "1600000002.000100"}]`,
+		"Sales/announcements/2020-09-13.json": `[{"type": "message", "user": "U1", "text": "Org news", "ts": "1600000001.000100"}]`,
+	})
+}
+
+func TestIsGridExport(t *testing.T) {
+	assert.True(t, IsGridExport(createGridZipReader(t)))
+	assert.False(t, IsGridExport(createZipReader(t, map[string]string{
+		"channels.json":              `[]`,
+		"users.json":                 `[]`,
+		"general/2020-09-13.json":    `[]`,
+		"__uploads/F1/channels.json": `[]`,
+	})))
+}
+
+func TestWorkspaceTeamName(t *testing.T) {
+	slackTransformer := NewTransformer("org", log.New())
+	slackTransformer.WorkspaceTeams = map[string]string{"Sales": "sales-team"}
+
+	assert.Equal(t, "org", slackTransformer.workspaceTeamName(""))
+	assert.Equal(t, "sales-team", slackTransformer.workspaceTeamName("Sales"))
+
+	assert.NoError(t, slackTransformer.checkWorkspaceTeams([]string{"Sales"}))
+	err := slackTransformer.checkWorkspaceTeams([]string{"Engineering", "Sales", "R&D Europe"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Engineering, R&D Europe")
+}
+
+func TestWorkspaceReportPath(t *testing.T) {
+	assert.Equal(t, "reports/archived.json", workspaceReportPath("reports/archived.json", DefaultArchivedChannelsReportPath, ""))
+	assert.Equal(t, "reports/archived-Sales.json", workspaceReportPath("reports/archived.json", DefaultArchivedChannelsReportPath, "Sales"))
+	assert.Equal(t, "archived-channels-Sales.json", workspaceReportPath("", DefaultArchivedChannelsReportPath, "Sales"))
+}
+
+func TestParseSlackGridExportFile(t *testing.T) {
+	slackTransformer := NewTransformer("org", log.New())
+	slackTransformer.WorkspaceTeams = map[string]string{"Engineering": "engineering", "Sales": "sales"}
+	workspaces, err := slackTransformer.ParseSlackGridExportFile(createGridZipReader(t), true)
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+
+	engineering, sales := workspaces[0], workspaces[1]
+	assert.Equal(t, "Engineering", engineering.Name)
+	assert.Equal(t, "engineering", engineering.Export.TeamName)
+	assert.Len(t, engineering.Export.Users, 3)
+	assert.Len(t, engineering.Export.PublicChannels, 2)
+	assert.Len(t, engineering.Export.Posts, 2)
+
+	// the channel shared by both workspaces is only kept in the first one
+	assert.Equal(t, "Sales", sales.Name)
+	assert.Len(t, sales.Export.Users, 3)
+	require.Len(t, sales.Export.PublicChannels, 1)
+	assert.Equal(t, "C2", sales.Export.PublicChannels[0].Id)
+	assert.Len(t, sales.Export.Posts, 1)
+	assert.Contains(t, sales.Export.Posts, "general")
+}
+
+func TestGridWorkspaceMembers(t *testing.T) {
+	slackTransformer := NewTransformer("org", log.New())
+	slackTransformer.WorkspaceTeams = map[string]string{"Engineering": "engineering", "Sales": "sales"}
+	workspaces, err := slackTransformer.ParseSlackGridExportFile(createGridZipReader(t), true)
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+
+	// bob is a member of an Engineering channel, but of the Sales workspace only
+	assert.Equal(t, map[string]bool{"U1": true, "U3": true}, gridWorkspaceMembers(workspaces[0]))
+	assert.Equal(t, map[string]bool{"U2": true, "U3": true}, gridWorkspaceMembers(workspaces[1]))
+	assert.Empty(t, gridWorkspaceMembers(&SlackWorkspaceExport{Export: workspaces[0].Export}))
+}
+
+func TestTransformAndExportGrid(t *testing.T) {
+	slackTransformer := NewTransformer("org", log.New())
+	slackTransformer.WorkspaceTeams = map[string]string{"Engineering": "engineering", "Sales": "sales"}
+	workspaces, err := slackTransformer.ParseSlackGridExportFile(createGridZipReader(t), true)
+	require.NoError(t, err)
+	require.NoError(t, slackTransformer.TransformGrid(workspaces, "", true, false, false, false, false))
+
+	require.Len(t, slackTransformer.workspaceTransformers, 2)
+	for _, user := range slackTransformer.workspaceTransformers[1].Intermediate.UsersById {
+		assert.Empty(t, user.HomeTeam, "users of the organisation are not external")
+	}
+
+	outputFilePath := filepath.Join(t.TempDir(), "output.jsonl")
+	require.NoError(t, slackTransformer.Export(outputFilePath))
+	b, err := os.ReadFile(outputFilePath)
+	require.NoError(t, err)
+	output := string(b)
+
+	assert.Equal(t, 1, strings.Count(output, `"username":"alice"`))
+	assert.Equal(t, 1, strings.Count(output, `"username":"carol"`))
+	assert.Contains(t, output, `"team":"engineering","name":"general"`)
+	assert.Contains(t, output, `"team":"sales","name":"general"`)
+	assert.Contains(t, output, `"team":"sales","channel":"general"`)
+	assert.Equal(t, 1, strings.Count(output, `"message":"Org news"`))
+
+	var carolLine string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, `"username":"carol"`) {
+			carolLine = line
+		}
+	}
+	assert.Contains(t, carolLine, `"name":"engineering"`)
+	assert.Contains(t, carolLine, `"name":"sales"`)
+}
+
+func TestTransformGridSharesUsers(t *testing.T) {
+	reportDir := t.TempDir()
+	slackTransformer := NewTransformer("org", log.New())
+	slackTransformer.WorkspaceTeams = map[string]string{"Engineering": "engineering", "Sales": "sales"}
+	slackTransformer.TimezonesScriptPath = filepath.Join(reportDir, "timezones.sh")
+	workspaces, err := slackTransformer.ParseSlackGridExportFile(createGridZipReader(t), true)
+	require.NoError(t, err)
+	require.NoError(t, slackTransformer.TransformGrid(workspaces, "", true, false, false, false, false))
+
+	require.Len(t, slackTransformer.Intermediate.UsersById, 3)
+	require.Len(t, slackTransformer.workspaceTransformers, 2)
+	engineering, sales := slackTransformer.workspaceTransformers[0], slackTransformer.workspaceTransformers[1]
+	assert.Equal(t, slackTransformer.Intermediate.UsersById["U1"].Password, engineering.Intermediate.UsersById["U1"].Password)
+	assert.Equal(t, slackTransformer.Intermediate.UsersById["U1"].Password, sales.Intermediate.UsersById["U1"].Password)
+	assert.Equal(t, []string{"general", "announcements"}, engineering.Intermediate.UsersById["U3"].Memberships)
+	assert.Equal(t, []string{"general"}, sales.Intermediate.UsersById["U3"].Memberships)
+
+	require.NoError(t, slackTransformer.Export(filepath.Join(t.TempDir(), "output.jsonl")))
+	files, err := os.ReadDir(reportDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "timezones.sh", files[0].Name())
+}
+
+func TestPrecheckGrid(t *testing.T) {
+	slackTransformer := NewTransformer("org", log.New())
+	assert.True(t, slackTransformer.Precheck(createGridZipReader(t)))
+	assert.False(t, slackTransformer.Precheck(createZipReader(t, map[string]string{"Sales/channels.json": `[]`})))
+}
+
+func TestTransformGridSharesEmojis(t *testing.T) {
+	emojiDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(emojiDir, "shipit.png"), []byte("image data"), 0600))
+
+	slackTransformer := NewTransformer("org", log.New())
+	slackTransformer.WorkspaceTeams = map[string]string{"Engineering": "engineering", "Sales": "sales"}
+	require.NoError(t, slackTransformer.ParseCustomEmoji(emojiDir))
+	workspaces, err := slackTransformer.ParseSlackGridExportFile(createGridZipReader(t), true)
+	require.NoError(t, err)
+	require.NoError(t, slackTransformer.TransformGrid(workspaces, t.TempDir(), false, false, false, false, false))
+
+	for _, wt := range slackTransformer.workspaceTransformers {
+		require.Contains(t, wt.Intermediate.Emojis, "shipit")
+		assert.Equal(t, "shipit", wt.SlackConvertEmojiName("shipit"))
+	}
+
+	outputFilePath := filepath.Join(t.TempDir(), "output.jsonl")
+	require.NoError(t, slackTransformer.Export(outputFilePath))
+	b, err := os.ReadFile(outputFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(b), `"type":"emoji"`))
+}
@@ -36,6 +36,13 @@ type IntermediateChannel struct {
 	Header           string            `json:"header"`
 	Topic            string            `json:"topic"`
 	Type             model.ChannelType `json:"type"`
+	Creator          string            `json:"creator"`
+	Admins           []string          `json:"admins"`
+	IsArchived       bool              `json:"is_archived"`
+	// Bookmarks is the list of the channel bookmarks that didn't fit in
+	// the header, to be imported as a pinned post.
+	Bookmarks         string `json:"bookmarks"`
+	BookmarksUpdateAt int64  `json:"bookmarks_update_at"`
 }
 
 func (c *IntermediateChannel) Sanitise(logger log.FieldLogger) {
@@ -78,12 +85,30 @@ func (c *IntermediateChannel) Sanitise(logger log.FieldLogger) {
 type IntermediateUser struct {
 	Id          string   `json:"id"`
 	Username    string   `json:"username"`
+	Nickname    string   `json:"nickname"`
 	FirstName   string   `json:"first_name"`
 	LastName    string   `json:"last_name"`
 	Position    string   `json:"position"`
 	Email       string   `json:"email"`
 	Password    string   `json:"password"`
 	Memberships []string `json:"memberships"`
+	// AdminMemberships holds the memberships in which the user is a
+	// channel admin.
+	AdminMemberships []string `json:"admin_memberships"`
+	DeleteAt         int64    `json:"delete_at"`
+	ProfileImage     string   `json:"profile_image"`
+	// Roles, TeamRoles and ChannelRoles default to the regular user roles
+	// when empty.
+	Roles        string `json:"roles"`
+	TeamRoles    string `json:"team_roles"`
+	ChannelRoles string `json:"channel_roles"`
+	Locale       string `json:"locale"`
+	// Timezone is the IANA name of the user's timezone. It can't be
+	// imported and is set after the import by the timezones script.
+	Timezone string `json:"timezone"`
+	// HomeTeam is the ID of the Slack workspace of users from other
+	// organisations, such as Slack Connect users.
+	HomeTeam string `json:"home_team"`
 }
 
 func (u *IntermediateUser) Sanitise(logger log.FieldLogger) {
@@ -91,14 +116,23 @@ func (u *IntermediateUser) Sanitise(logger log.FieldLogger) {
 		u.Email = u.Username + "@example.com"
 		logger.Warnf("User %s does not have an email address in the Slack export. Used %s as a placeholder. The user should update their email address once logged in to the system.", u.Username, u.Email)
 	}
+
+	if utf8.RuneCountInString(u.Nickname) > model.UserNicknameMaxRunes {
+		logger.Warnf("User %s nickname exceeds the maximum length. It will be truncated when imported.", u.Username)
+		u.Nickname = truncateRunes(u.Nickname, model.UserNicknameMaxRunes)
+	}
 }
 
 type IntermediatePost struct {
 	User           string                        `json:"user"`
 	Channel        string                        `json:"channel"`
 	Message        string                        `json:"message"`
+	Type           string                        `json:"type"`
 	Props          model.StringInterface         `json:"props"`
 	CreateAt       int64                         `json:"create_at"`
+	EditAt         int64                         `json:"edit_at"`
+	IsPinned       bool                          `json:"is_pinned"`
+	FlaggedBy      []string                      `json:"flagged_by"`
 	Attachments    []string                      `json:"attachments"`
 	Replies        []*IntermediatePost           `json:"replies"`
 	IsDirect       bool                          `json:"is_direct"`
@@ -115,6 +149,19 @@ type Intermediate struct {
 	Posts            []*IntermediatePost             `json:"posts"`
 	UserOverrides    map[string]*IntermediateUser    `json:"user_overrides"`
 	ChannelOverrides map[string]*IntermediateChannel `json:"channel_overrides"`
+	ChannelAdmins    map[string][]string             `json:"channel_admins"`
+	Emojis           map[string]*IntermediateEmoji   `json:"emojis"`
+	EmojiAliases     map[string]string               `json:"emoji_aliases"`
+	Usergroups       []*IntermediateUsergroup        `json:"usergroups"`
+	// OrphanedReplies holds the number of replies whose root post is
+	// missing, by channel name
+	OrphanedReplies map[string]int `json:"orphaned_replies"`
+	// ExternalFiles are the files shared from other services, which are
+	// imported as links
+	ExternalFiles []ExternalFile `json:"external_files"`
+	// UnavailableContent holds how much content is missing from the
+	// export, by channel name and reason
+	UnavailableContent map[string]map[UnavailableContentReason]int `json:"unavailable_content"`
 }
 
 func (t *Transformer) ParseUserOverrides(userOverridesFile *os.File) error {
@@ -146,6 +193,8 @@ func (t *Transformer) ParseUserOverrides(userOverridesFile *os.File) error {
 				key = field
 			case "username":
 				overrideUser.Username = field
+			case "nickname":
+				overrideUser.Nickname = field
 			case "first_name":
 				overrideUser.FirstName = field
 			case "last_name":
@@ -177,6 +226,12 @@ func (t *Transformer) ApplyUserOverrides(user *IntermediateUser) {
 		if overrideUser.Username != "" {
 			user.Username = overrideUser.Username
 		}
+		if overrideUser.Nickname != "" {
+			user.Nickname = overrideUser.Nickname
+			if user.Nickname == "-" {
+				user.Nickname = ""
+			}
+		}
 		if overrideUser.FirstName != "" {
 			user.FirstName = overrideUser.FirstName
 			if user.FirstName == "-" {
@@ -209,22 +264,46 @@ func (t *Transformer) ApplyUserOverrides(user *IntermediateUser) {
 func (t *Transformer) TransformUsers(users []SlackUser) {
 	t.Logger.Info("Transforming users")
 
+	homeTeamId := getHomeTeamId(users)
 	resultUsers := map[string]*IntermediateUser{}
 	for _, user := range users {
+		nickname, firstName, lastName := t.SlackConvertUserNames(user.Profile)
 		newUser := &IntermediateUser{
 			Id:        user.Id,
 			Username:  user.Username,
-			FirstName: user.Profile.FirstName,
-			LastName:  user.Profile.LastName,
+			Nickname:  nickname,
+			FirstName: firstName,
+			LastName:  lastName,
 			Position:  user.Profile.Title,
 			Email:     user.Profile.Email,
 			Password:  model.NewId(),
+			Locale:    SlackConvertLocale(user.Locale),
+			Timezone:  SlackConvertTimezone(user.TZ, user.TZOffset),
 		}
 
 		if user.IsBot {
 			newUser.Id = user.Profile.BotID
 		}
 
+		newUser.Roles, newUser.TeamRoles, newUser.ChannelRoles = t.SlackConvertUserRoles(user)
+
+		if isExternalUser(user, homeTeamId) {
+			newUser.HomeTeam = user.TeamId
+			if t.ExternalUsersAsGuests {
+				newUser.Roles, newUser.TeamRoles, newUser.ChannelRoles = model.SystemGuestRoleId, model.TeamGuestRoleId, model.ChannelGuestRoleId
+			}
+		}
+
+		// deactivated users keep their memberships so that their
+		// history is still attributed to them
+		if user.Deleted {
+			newUser.DeleteAt = user.Updated * 1000
+			if newUser.DeleteAt == 0 {
+				// same as bad timestamps, so the output is reproducible
+				newUser.DeleteAt = 1
+			}
+		}
+
 		t.ApplyUserOverrides(newUser)
 
 		newUser.Sanitise(t.Logger)
@@ -344,9 +423,75 @@ func (t *Transformer) ApplyChannelOverrides(channel *IntermediateChannel) {
 	}
 }
 
+func (t *Transformer) ParseChannelAdmins(channelAdminsFile *os.File) error {
+	t.Intermediate.ChannelAdmins = map[string][]string{}
+	if channelAdminsFile == nil {
+		return nil
+	}
+	t.Logger.Info("Parsing channel admins")
+	reader := csv.NewReader(channelAdminsFile)
+	headers, err := reader.Read()
+	if err != nil {
+		t.Logger.Error(err.Error())
+		return err
+	}
+	count := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Logger.Error(err.Error())
+			return err
+		}
+		channelName := ""
+		username := ""
+		for i, field := range record {
+			switch headers[i] {
+			case "channel_name":
+				channelName = field
+			case "username":
+				username = field
+			default:
+				t.Logger.Warnf("Unknown field %s in channel admin record", headers[i])
+			}
+		}
+		if channelName == "" || username == "" {
+			return errors.New("channel admin record does not have both a channel_name and a username value")
+		}
+		t.Intermediate.ChannelAdmins[channelName] = append(t.Intermediate.ChannelAdmins[channelName], username)
+		count++
+	}
+	t.Logger.Infof("Parsed %d channel admins", count)
+	return nil
+}
+
+// getChannelAdmins returns the IDs of the users that should be admins of the
+// channel: its creator and the users listed for it in the channel admins file.
+func (t *Transformer) getChannelAdmins(channel SlackChannel, validMembers []string) []string {
+	admins := []string{}
+	for _, memberId := range validMembers {
+		if memberId == channel.Creator {
+			admins = append(admins, memberId)
+			continue
+		}
+		user := t.Intermediate.UsersById[memberId]
+		if user != nil && containsString(t.Intermediate.ChannelAdmins[getOriginalName(channel)], user.Username) {
+			admins = append(admins, memberId)
+		}
+	}
+	return admins
+}
+
 func (t *Transformer) TransformChannels(channels []SlackChannel, teamInternalOnly bool) []*IntermediateChannel {
 	resultChannels := []*IntermediateChannel{}
 	for _, channel := range channels {
+		if channel.IsArchived && t.ArchivedChannels == ArchivedChannelsSkip {
+			t.Logger.Infof("Skipping channel %s as it is archived", getOriginalName(channel))
+			continue
+		}
+
 		validMembers := filterValidMembers(channel.Members, t.Intermediate.UsersById)
 		if (channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup) && len(validMembers) <= 1 {
 			t.Logger.Warnf("Bulk export for direct channels containing a single member is not supported. Not importing channel %s", channel.Name)
@@ -384,6 +529,7 @@ func (t *Transformer) TransformChannels(channels []SlackChannel, teamInternalOnl
 		}
 
 		newChannel := &IntermediateChannel{
+			Id:           channel.Id,
 			OriginalName: getOriginalName(channel),
 			Name:         name,
 			DisplayName:  name,
@@ -391,6 +537,9 @@ func (t *Transformer) TransformChannels(channels []SlackChannel, teamInternalOnl
 			Purpose:      channel.Purpose.Value,
 			Header:       channel.Topic.Value,
 			Type:         channel.Type,
+			Creator:      channel.Creator,
+			Admins:       t.getChannelAdmins(channel, validMembers),
+			IsArchived:   channel.IsArchived,
 		}
 
 		if teamInternalOnly && (newChannel.Type == model.ChannelTypeDirect || newChannel.Type == model.ChannelTypeGroup) {
@@ -398,7 +547,10 @@ func (t *Transformer) TransformChannels(channels []SlackChannel, teamInternalOnl
 		}
 
 		if newChannel.Type == model.ChannelTypeOpen || newChannel.Type == model.ChannelTypePrivate {
+			overrideChannel, ok := t.Intermediate.ChannelOverrides[newChannel.Name]
+			headerOverridden := ok && overrideChannel.Header != ""
 			t.ApplyChannelOverrides(newChannel)
+			t.addBookmarksToChannel(newChannel, channel.Bookmarks, channel.Created, headerOverridden)
 		}
 
 		newChannel.Sanitise(t.Logger)
@@ -413,10 +565,14 @@ func (t *Transformer) PopulateUserMemberships() {
 
 	for userId, user := range t.Intermediate.UsersById {
 		memberships := []string{}
+		adminMemberships := []string{}
 		for _, channel := range t.Intermediate.PublicChannels {
 			for _, memberId := range channel.Members {
 				if userId == memberId {
 					memberships = append(memberships, channel.Name)
+					if containsString(channel.Admins, userId) {
+						adminMemberships = append(adminMemberships, channel.Name)
+					}
 					break
 				}
 			}
@@ -425,11 +581,15 @@ func (t *Transformer) PopulateUserMemberships() {
 			for _, memberId := range channel.Members {
 				if userId == memberId {
 					memberships = append(memberships, channel.Name)
+					if containsString(channel.Admins, userId) {
+						adminMemberships = append(adminMemberships, channel.Name)
+					}
 					break
 				}
 			}
 		}
 		user.Memberships = memberships
+		user.AdminMemberships = adminMemberships
 	}
 }
 
@@ -488,7 +648,15 @@ func (t *Transformer) TransformAllChannels(slackExport *SlackExport, teamInterna
 	return nil
 }
 
-func AddPostToThreads(original SlackPost, post *IntermediatePost, threads map[string]*IntermediatePost, channel *IntermediateChannel, timestamps map[int64]bool) {
+// AddPostToThreads adds the post to the thread it belongs to, or as a new
+// thread. It returns false without adding the post if it is a reply whose
+// root post can't be found.
+func AddPostToThreads(original SlackPost, post *IntermediatePost, threads map[string]*IntermediatePost, channel *IntermediateChannel, timestamps map[int64]bool) bool {
+	isReply := original.ThreadTS != "" && original.ThreadTS != original.TimeStamp
+	if isReply && threads[original.ThreadTS] == nil {
+		return false
+	}
+
 	// direct and group posts need the channel members in the import line
 	if channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup {
 		post.IsDirect = true
@@ -507,15 +675,22 @@ func AddPostToThreads(original SlackPost, post *IntermediatePost, threads map[st
 	}
 	timestamps[post.CreateAt] = true
 
+	// the creation time may have been moved forward, so make sure the
+	// post isn't edited before it was created
+	if post.EditAt != 0 && post.EditAt < post.CreateAt {
+		post.EditAt = post.CreateAt
+	}
+
 	// if post is part of a thread
-	if original.ThreadTS != "" && original.ThreadTS != original.TimeStamp {
-		rootPost, ok := threads[original.ThreadTS]
-		if !ok {
-			log.Printf("ERROR processing post in thread, couldn't find rootPost: %+v\n", original)
-			return
+	if isReply {
+		rootPost := threads[original.ThreadTS]
+		// replies can't be pinned in Mattermost, so we pin the thread instead
+		if post.IsPinned && !rootPost.IsPinned {
+			log.Printf("WARNING: pinning root post of thread %s since one of its replies is pinned\n", original.ThreadTS)
+			rootPost.IsPinned = true
 		}
 		rootPost.Replies = append(rootPost.Replies, post)
-		return
+		return true
 	}
 
 	// if post is the root of a thread
@@ -524,7 +699,7 @@ func AddPostToThreads(original SlackPost, post *IntermediatePost, threads map[st
 			log.Println("WARNING: overwriting root post for thread " + original.ThreadTS)
 		}
 		threads[original.ThreadTS] = post
-		return
+		return true
 	}
 
 	if threads[original.TimeStamp] != nil {
@@ -532,6 +707,7 @@ func AddPostToThreads(original SlackPost, post *IntermediatePost, threads map[st
 	}
 
 	threads[original.TimeStamp] = post
+	return true
 }
 
 func buildChannelsByOriginalNameMap(intermediate *Intermediate) map[string]*IntermediateChannel {
@@ -551,6 +727,23 @@ func buildChannelsByOriginalNameMap(intermediate *Intermediate) map[string]*Inte
 	return channelsByName
 }
 
+// buildPinnedTimestampsMap returns, for each channel original name, the
+// timestamps of the messages pinned in that channel mapped to the channel ID.
+func buildPinnedTimestampsMap(channels []SlackChannel) map[string]map[string]string {
+	pinnedTimestamps := map[string]map[string]string{}
+	for _, channel := range channels {
+		if len(channel.Pins) == 0 {
+			continue
+		}
+		timestamps := map[string]string{}
+		for _, pin := range channel.Pins {
+			timestamps[pin.Id] = channel.Id
+		}
+		pinnedTimestamps[getOriginalName(channel)] = timestamps
+	}
+	return pinnedTimestamps
+}
+
 func getNormalisedFilePath(file *SlackFile, attachmentsDir string) string {
 	n := makeAlphaNum(file.Name, '.', '-', '_')
 	p := path.Join(attachmentsDir, file.Id, n)
@@ -653,11 +846,15 @@ func addZipFileToPost(file *SlackFile, uploads map[string]*zip.File, post *Inter
 }
 
 func (t *Transformer) CreateIntermediateUser(userID string) {
+	t.createNamedIntermediateUser(userID, "Deleted", "User")
+}
+
+func (t *Transformer) createNamedIntermediateUser(userID, firstName, lastName string) {
 	newUser := &IntermediateUser{
 		Id:        userID,
 		Username:  strings.ToLower(userID),
-		FirstName: "Deleted",
-		LastName:  "User",
+		FirstName: firstName,
+		LastName:  lastName,
 		Email:     fmt.Sprintf("%s@local", userID),
 		Password:  model.NewId(),
 	}
@@ -696,6 +893,22 @@ func (t *Transformer) SlackConvertReactions(slackReactions *[]SlackReaction, pos
 	return &ret
 }
 
+func (t *Transformer) SlackConvertStarredBy(starredBy []string) []string {
+	if len(starredBy) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(starredBy))
+	for _, userId := range starredBy {
+		user := t.Intermediate.UsersById[userId]
+		if user == nil {
+			t.CreateIntermediateUser(userId)
+			user = t.Intermediate.UsersById[userId]
+		}
+		ret = append(ret, user.Username)
+	}
+	return ret
+}
+
 func (t *Transformer) SlackConvertEmojiName(slackEmojiName string) string {
 	ret := slackEmojiName
 	// Take care of skin tones
@@ -719,7 +932,14 @@ func (t *Transformer) SlackConvertEmojiName(slackEmojiName string) string {
 		// Replace ":" with "_"
 		ret = strings.Replace(ret, ":", "_", -1)
 	}
+	// Custom emoji aliases are imported as the emoji they point to
+	if target, ok := t.Intermediate.EmojiAliases[ret]; ok {
+		ret = target
+	}
 	// Warn about unsupported emoji
+	if _, ok := t.Intermediate.Emojis[ret]; ok {
+		return ret
+	}
 	if _, ok := supportedEmojis[ret]; !ok {
 		t.Logger.Warnf("Unsupported emoji. emoji=%s", ret)
 	}
@@ -741,6 +961,17 @@ var supportedEmojis = (func() map[string]bool {
 })()
 
 func (t *Transformer) CreateAndAddPostToThreads(post SlackPost, threads map[string]*IntermediatePost, timestamps map[int64]bool, channel *IntermediateChannel, discardInvalidProps, addOriginal bool) {
+	newPost := t.createPost(post, channel, discardInvalidProps, addOriginal)
+	if newPost == nil {
+		return
+	}
+
+	t.addPostToThreads(post, newPost, threads, channel, timestamps)
+}
+
+// createPost creates the intermediate post for a Slack post without files. It
+// returns nil if the post has to be discarded.
+func (t *Transformer) createPost(post SlackPost, channel *IntermediateChannel, discardInvalidProps, addOriginal bool) *IntermediatePost {
 	author := t.Intermediate.UsersById[post.User]
 	if author == nil {
 		t.CreateIntermediateUser(post.User)
@@ -753,6 +984,9 @@ func (t *Transformer) CreateAndAddPostToThreads(post SlackPost, threads map[stri
 		Channel:   channel.Name,
 		Message:   post.Text,
 		CreateAt:  createAt,
+		EditAt:    post.EditAt(),
+		IsPinned:  post.IsPinned(),
+		FlaggedBy: t.SlackConvertStarredBy(post.StarredBy),
 		Reactions: t.SlackConvertReactions(post.Reactions, createAt),
 	}
 
@@ -762,27 +996,47 @@ func (t *Transformer) CreateAndAddPostToThreads(post SlackPost, threads map[stri
 	} else {
 		if discardInvalidProps {
 			t.Logger.Warn("Unable to import the post as props exceed the maximum character count. Skipping as --discard-invalid-props is enabled.")
-			return
+			return nil
 		} else {
 			t.Logger.Warn("Unable to add the props to post as they exceed the maximum character count.")
 		}
 	}
 
-	AddPostToThreads(post, newPost, threads, channel, timestamps)
+	return newPost
 }
 
 func (t *Transformer) AddFilesToPost(post *SlackPost, skipAttachments bool, slackExport *SlackExport, attachmentsDir string, newPost *IntermediatePost, allowDownload bool) {
-	if skipAttachments || (post.File == nil && post.Files == nil) {
+	if post.File == nil && post.Files == nil {
+		return
+	}
+	// files stored in other services or missing from the export are
+	// handled even if attachments are skipped, as they are not uploaded
+	files := post.Files
+	if post.File != nil {
+		files = []*SlackFile{post.File}
+	}
+	for _, file := range files {
+		if isExternalFile(file, slackExport.Uploads) {
+			t.addExternalFileToPost(file, newPost)
+		} else if reason := file.unavailableReason(); reason != "" {
+			t.addUnavailableFileToPost(file, reason, newPost)
+		}
+	}
+	if skipAttachments {
 		return
 	}
+	// canvases are imported as posts of their own, and the content of
+	// snippets and Slack posts is added to the message
 	if post.File != nil {
+		if post.File.IsCanvas() || t.isInlinedOnly(post.File, slackExport) || isExternalFile(post.File, slackExport.Uploads) || post.File.unavailableReason() != "" {
+			return
+		}
 		if err := addFileToPost(post.File, slackExport.Uploads, newPost, attachmentsDir, allowDownload); err != nil {
 			t.Logger.WithError(err).Error("Failed to add file to post")
 		}
 	} else if post.Files != nil {
 		for _, file := range post.Files {
-			if file.Name == "" {
-				t.Logger.Warnf("Not able to access the file %s as file access is denied so skipping", file.Id)
+			if file.IsCanvas() || t.isInlinedOnly(file, slackExport) || isExternalFile(file, slackExport.Uploads) || file.unavailableReason() != "" {
 				continue
 			}
 			if err := addFileToPost(file, slackExport.Uploads, newPost, attachmentsDir, allowDownload); err != nil {
@@ -800,6 +1054,12 @@ func (t *Transformer) GetPropsForPost(post *SlackPost, addAttachments, addOrigin
 	if addOriginal {
 		props["slackOriginal"] = post.Original
 	}
+	// tombstones of deleted messages are bot messages too, but not from a bot
+	if post.Type == "message" && post.SubType == "bot_message" {
+		for key, value := range post.botProps() {
+			props[key] = value
+		}
+	}
 	if len(props) == 0 {
 		return nil, nil
 	}
@@ -810,14 +1070,31 @@ func (t *Transformer) GetPropsForPost(post *SlackPost, addAttachments, addOrigin
 func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir string, skipAttachments, discardInvalidProps, allowDownload, addOriginal bool) error {
 	t.Logger.Info("Transforming posts")
 
+	if t.UnavailableContent == UnavailableContentFail {
+		if err := checkUnavailableContent(slackExport); err != nil {
+			return err
+		}
+	}
+
 	newGroupChannels := []*IntermediateChannel{}
 	newDirectChannels := []*IntermediateChannel{}
 	channelsByOriginalName := buildChannelsByOriginalNameMap(t.Intermediate)
+	pinnedTimestampsByChannel := buildPinnedTimestampsMap(slackExport.Channels)
+	archivedChannels := map[string]bool{}
+	for _, channel := range slackExport.Channels {
+		if channel.IsArchived {
+			archivedChannels[getOriginalName(channel)] = true
+		}
+	}
 
 	resultPosts := []*IntermediatePost{}
 	for originalChannelName, channelPosts := range slackExport.Posts {
 		channel, ok := channelsByOriginalName[originalChannelName]
 		if !ok {
+			if archivedChannels[originalChannelName] && t.ArchivedChannels == ArchivedChannelsSkip {
+				t.Logger.Infof("Skipping %d posts of archived channel %s", len(channelPosts), originalChannelName)
+				continue
+			}
 			t.Logger.Warnf("--- Couldn't find channel %s referenced by posts", originalChannelName)
 			continue
 		}
@@ -829,8 +1106,14 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 			return SlackConvertTimeStampToMicroSeconds(channelPosts[i].TimeStamp) < SlackConvertTimeStampToMicroSeconds(channelPosts[j].TimeStamp)
 		})
 		threads := map[string]*IntermediatePost{}
+		systemEvents := &systemEventState{}
 
 		for _, post := range channelPosts {
+			// pins listed on the channel are not always present on the message
+			if channelId, ok := pinnedTimestampsByChannel[originalChannelName][post.TimeStamp]; ok && !post.IsPinned() {
+				post.PinnedTo = append(post.PinnedTo, channelId)
+			}
+
 			switch {
 			// plain message that can have files attached
 			case post.IsPlainMessage():
@@ -849,9 +1132,14 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 					Channel:   channel.Name,
 					Message:   post.Text,
 					CreateAt:  createAt,
+					EditAt:    post.EditAt(),
+					IsPinned:  post.IsPinned(),
+					FlaggedBy: t.SlackConvertStarredBy(post.StarredBy),
 					Reactions: t.SlackConvertReactions(post.Reactions, createAt),
 				}
 				t.AddFilesToPost(&post, skipAttachments, slackExport, attachmentsDir, newPost, allowDownload)
+				canvasPosts := t.AddCanvasesToPost(&post, skipAttachments, slackExport, attachmentsDir, newPost, allowDownload)
+				fileReplies := t.AddInlinedFilesToPost(&post, slackExport, newPost)
 
 				props, propsB := t.GetPropsForPost(&post, len(post.Attachments) > 0, addOriginal)
 				if utf8.RuneCount(propsB) <= model.PostPropsMaxRunes {
@@ -865,7 +1153,10 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 					}
 				}
 
-				AddPostToThreads(post, newPost, threads, channel, timestamps)
+				t.addPostToThreads(post, newPost, threads, channel, timestamps)
+				addCanvasPostsToThreads(post, canvasPosts, threads, channel, timestamps)
+				addInlinedFileRepliesToThreads(post, fileReplies, threads, channel, timestamps)
+				t.addThreadBroadcastToChannel(post, newPost, threads, channel, timestamps)
 
 			// file comment
 			case post.IsFileComment():
@@ -888,6 +1179,9 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 					Channel:   channel.Name,
 					Message:   post.Comment.Comment,
 					CreateAt:  createAt,
+					EditAt:    post.EditAt(),
+					IsPinned:  post.IsPinned(),
+					FlaggedBy: t.SlackConvertStarredBy(post.StarredBy),
 					Reactions: t.SlackConvertReactions(post.Reactions, createAt),
 				}
 
@@ -903,7 +1197,16 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 					}
 				}
 
-				AddPostToThreads(post, newPost, threads, channel, timestamps)
+				t.addPostToThreads(post, newPost, threads, channel, timestamps)
+
+			// deleted message, which can still have replies
+			case post.IsTombstone():
+				t.countUnavailableContent(channel.Name, UnavailableDeletedPost)
+				if t.UnavailableContent != UnavailableContentPlaceholder {
+					t.recordDeletedRoot(post, channel)
+					continue
+				}
+				t.addDeletedPostToThreads(post, threads, channel, timestamps)
 
 			// bot message
 			case post.IsBotMessage():
@@ -917,7 +1220,12 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 
 				author := t.Intermediate.UsersById[post.BotId]
 				if author == nil {
-					t.CreateIntermediateUser(post.BotId)
+					// name the user after the bot rather than as a deleted user
+					if post.BotProfile != nil && post.BotProfile.Name != "" {
+						t.createNamedIntermediateUser(post.BotId, post.BotProfile.Name, "")
+					} else {
+						t.CreateIntermediateUser(post.BotId)
+					}
 					author = t.Intermediate.UsersById[post.BotId]
 				}
 
@@ -927,10 +1235,15 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 					Channel:   channel.Name,
 					Message:   post.Text,
 					CreateAt:  createAt,
+					EditAt:    post.EditAt(),
+					IsPinned:  post.IsPinned(),
+					FlaggedBy: t.SlackConvertStarredBy(post.StarredBy),
 					Reactions: t.SlackConvertReactions(post.Reactions, createAt),
 				}
 
 				t.AddFilesToPost(&post, skipAttachments, slackExport, attachmentsDir, newPost, allowDownload)
+				canvasPosts := t.AddCanvasesToPost(&post, skipAttachments, slackExport, attachmentsDir, newPost, allowDownload)
+				fileReplies := t.AddInlinedFilesToPost(&post, slackExport, newPost)
 
 				props, propsB := t.GetPropsForPost(&post, len(post.Attachments) > 0, addOriginal)
 				if utf8.RuneCount(propsB) <= model.PostPropsMaxRunes {
@@ -944,48 +1257,17 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 					}
 				}
 
-				AddPostToThreads(post, newPost, threads, channel, timestamps)
-
-			// channel join/leave messages
-			case post.IsJoinLeaveMessage():
-				if post.User == "" {
-					t.Logger.Warn("Unable to import the message as the user field is missing.")
-					continue
-				}
-
-				t.CreateAndAddPostToThreads(post, threads, timestamps, channel, discardInvalidProps, addOriginal)
-
-			// me message
-			case post.IsMeMessage():
-				if post.User == "" {
-					t.Logger.Warn("Unable to import the message as the user field is missing.")
-					continue
-				}
-				t.CreateAndAddPostToThreads(post, threads, timestamps, channel, discardInvalidProps, addOriginal)
-
-			// change topic message
-			case post.IsChannelTopicMessage():
-				if post.User == "" {
-					t.Logger.Warn("Unable to import the message as the user field is missing.")
-					continue
-				}
-				t.CreateAndAddPostToThreads(post, threads, timestamps, channel, discardInvalidProps, addOriginal)
+				t.addPostToThreads(post, newPost, threads, channel, timestamps)
+				addCanvasPostsToThreads(post, canvasPosts, threads, channel, timestamps)
+				addInlinedFileRepliesToThreads(post, fileReplies, threads, channel, timestamps)
 
-			// change channel purpose message
-			case post.IsChannelPurposeMessage():
+			// system events, such as joins and channel topic changes
+			case post.SystemEventCategory() != "":
 				if post.User == "" {
 					t.Logger.Warn("Unable to import the message as the user field is missing.")
 					continue
 				}
-				t.CreateAndAddPostToThreads(post, threads, timestamps, channel, discardInvalidProps, addOriginal)
-
-			// change channel name message
-			case post.IsChannelNameMessage():
-				if post.User == "" {
-					t.Logger.Warn("Slack Import: Unable to import the message as the user field is missing.")
-					continue
-				}
-				t.CreateAndAddPostToThreads(post, threads, timestamps, channel, discardInvalidProps, addOriginal)
+				t.CreateAndAddSystemPostToThreads(post, systemEvents, threads, timestamps, channel, discardInvalidProps, addOriginal)
 
 			default:
 				t.Logger.Warnf("Unable to import the message as its type is not supported. post_type=%s, post_subtype=%s", post.Type, post.SubType)
@@ -1060,15 +1342,38 @@ func (t *Transformer) TransformPosts(slackExport *SlackExport, attachmentsDir st
 		}
 	}
 
+	t.logOrphanedReplies()
+	t.logUnavailableContent()
+
 	t.Intermediate.Posts = resultPosts
 	t.Intermediate.GroupChannels = append(t.Intermediate.GroupChannels, newGroupChannels...)
 	t.Intermediate.DirectChannels = append(t.Intermediate.DirectChannels, newDirectChannels...)
 
+	t.rewritePermalinks()
+	t.convertEmojiAliases()
+
 	return nil
 }
 
 func (t *Transformer) Transform(slackExport *SlackExport, attachmentsDir string, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly bool) error {
 	t.TransformUsers(slackExport.Users)
+	if !skipAttachments {
+		t.TransformProfileImages(slackExport, attachmentsDir, allowDownload)
+	}
+
+	return t.transformTeam(slackExport, attachmentsDir, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly)
+}
+
+// transformTeam transforms the content of the export that belongs to the team,
+// once its users have been transformed.
+func (t *Transformer) transformTeam(slackExport *SlackExport, attachmentsDir string, skipAttachments, discardInvalidProps, allowDownload, addOriginal, teamInternalOnly bool) error {
+	t.TransformUsergroups(slackExport.Usergroups)
+
+	if !skipAttachments {
+		t.TransformEmojis(attachmentsDir, allowDownload)
+	} else {
+		t.dropEmojis()
+	}
 
 	if err := t.TransformAllChannels(slackExport, teamInternalOnly); err != nil {
 		return err
@@ -1081,6 +1386,11 @@ func (t *Transformer) Transform(slackExport *SlackExport, attachmentsDir string,
 		return err
 	}
 
+	if !skipAttachments {
+		t.TransformChannelCanvases(slackExport, attachmentsDir)
+	}
+	t.TransformBookmarks()
+
 	return nil
 }
 
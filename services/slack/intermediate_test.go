@@ -2,6 +2,9 @@ package slack
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 	"testing"
 
@@ -12,6 +15,32 @@ import (
 	"github.com/mattermost/mattermost-server/v6/model"
 )
 
+// transformTestPosts transforms the posts of public channels whose members
+// are alice (U1) and bob (U2). The channels are named after the keys of the
+// posts and have the IDs C1, C2... in alphabetical order. It returns the
+// transformed posts sorted by creation time.
+func transformTestPosts(t *testing.T, slackTransformer *Transformer, posts map[string][]SlackPost) []*IntermediatePost {
+	t.Helper()
+	slackTransformer.TransformUsers([]SlackUser{{Id: "U1", Username: "alice"}, {Id: "U2", Username: "bob"}})
+
+	names := []string{}
+	for name := range posts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	channels := []SlackChannel{}
+	for i, name := range names {
+		channels = append(channels, SlackChannel{Id: fmt.Sprintf("C%d", i+1), Name: name, Members: []string{"U1", "U2"}, Type: model.ChannelTypeOpen})
+	}
+	slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels(channels, false)
+
+	require.NoError(t, slackTransformer.TransformPosts(&SlackExport{Posts: posts}, "", true, false, false, false))
+
+	result := slackTransformer.Intermediate.Posts
+	sort.Slice(result, func(i, j int) bool { return result[i].CreateAt < result[j].CreateAt })
+	return result
+}
+
 func TestIntermediateChannelSanitise(t *testing.T) {
 	t.Run("Properties should respect the max length", func(t *testing.T) {
 		channel := IntermediateChannel{
@@ -615,3 +644,265 @@ func TestAddPostToThreads(t *testing.T) {
 		}
 	})
 }
+
+func TestAddPostToThreadsPinnedReply(t *testing.T) {
+	channel := &IntermediateChannel{Type: model.ChannelTypeOpen}
+	threads := map[string]*IntermediatePost{}
+	timestamps := map[int64]bool{}
+
+	root := &IntermediatePost{CreateAt: 1549307811071}
+	AddPostToThreads(SlackPost{TimeStamp: "1549307811.071000", ThreadTS: "1549307811.071000"}, root, threads, channel, timestamps)
+	require.False(t, root.IsPinned)
+
+	reply := &IntermediatePost{CreateAt: 1549307812000, IsPinned: true}
+	AddPostToThreads(SlackPost{TimeStamp: "1549307812.000000", ThreadTS: "1549307811.071000"}, reply, threads, channel, timestamps)
+
+	require.Len(t, root.Replies, 1)
+	assert.True(t, root.IsPinned)
+}
+
+func TestTransformPostsPinnedByChannel(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.Intermediate.UsersById = map[string]*IntermediateUser{"U1": {Id: "U1", Username: "u1"}}
+	slackTransformer.Intermediate.PublicChannels = []*IntermediateChannel{{OriginalName: "general", Name: "general", Type: model.ChannelTypeOpen}}
+
+	slackExport := &SlackExport{
+		Channels: []SlackChannel{{Id: "C1", Name: "general", Pins: []SlackPin{{Id: "1549307812.000000"}}}},
+		Posts: map[string][]SlackPost{
+			"general": {
+				{Type: "message", User: "U1", Text: "not pinned", TimeStamp: "1549307811.000000"},
+				{Type: "message", User: "U1", Text: "pinned by the channel", TimeStamp: "1549307812.000000"},
+				{Type: "message", User: "U1", Text: "pinned by the message", TimeStamp: "1549307813.000000", PinnedTo: []string{"C1"}},
+			},
+		},
+	}
+
+	require.NoError(t, slackTransformer.TransformPosts(slackExport, "", true, false, false, false))
+	pinned := map[string]bool{}
+	for _, post := range slackTransformer.Intermediate.Posts {
+		pinned[post.Message] = post.IsPinned
+	}
+	assert.Equal(t, map[string]bool{"not pinned": false, "pinned by the channel": true, "pinned by the message": true}, pinned)
+}
+
+func TestTransformDeletedUsers(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	users := []SlackUser{
+		{Id: "id1", Username: "active"},
+		{Id: "id2", Username: "deactivated", Deleted: true, Updated: 1549307811},
+		{Id: "id3", Username: "undated", Deleted: true},
+	}
+
+	slackTransformer.TransformUsers(users)
+	require.Len(t, slackTransformer.Intermediate.UsersById, 3)
+	assert.Equal(t, int64(0), slackTransformer.Intermediate.UsersById["id1"].DeleteAt)
+	assert.Equal(t, int64(1549307811000), slackTransformer.Intermediate.UsersById["id2"].DeleteAt)
+	assert.Equal(t, int64(1), slackTransformer.Intermediate.UsersById["id3"].DeleteAt)
+
+	slackTransformer.Intermediate.UsersById["id2"].Memberships = []string{"c1"}
+	line := GetImportLineFromUser(slackTransformer.Intermediate.UsersById["id2"], "test")
+	require.NotNil(t, line.User.DeleteAt)
+	assert.Equal(t, int64(1549307811000), *line.User.DeleteAt)
+	require.Len(t, *(*line.User.Teams)[0].Channels, 1)
+
+	line = GetImportLineFromUser(slackTransformer.Intermediate.UsersById["id1"], "test")
+	assert.Nil(t, line.User.DeleteAt)
+}
+
+func TestTransformUserRoles(t *testing.T) {
+	users := []SlackUser{
+		{Id: "member", Username: "member"},
+		{Id: "admin", Username: "admin", IsAdmin: true},
+		{Id: "owner", Username: "owner", IsAdmin: true, IsOwner: true},
+		{Id: "primary", Username: "primary", IsAdmin: true, IsOwner: true, IsPrimaryOwner: true},
+		{Id: "guest", Username: "guest", IsRestricted: true},
+		{Id: "single", Username: "single", IsRestricted: true, IsUltraRestricted: true},
+	}
+
+	testCases := []struct {
+		Name        string
+		AdminPolicy AdminPolicy
+		GuestPolicy GuestPolicy
+		Expected    map[string][3]string
+	}{
+		{
+			Name:        "Default policies",
+			AdminPolicy: AdminPolicyTeam,
+			GuestPolicy: GuestPolicyGuest,
+			Expected: map[string][3]string{
+				"member":  {"system_user", "team_user", "channel_user"},
+				"admin":   {"system_user", "team_admin team_user", "channel_user"},
+				"owner":   {"system_user", "team_admin team_user", "channel_user"},
+				"primary": {"system_user", "team_admin team_user", "channel_user"},
+				"guest":   {"system_guest", "team_guest", "channel_guest"},
+				"single":  {"system_guest", "team_guest", "channel_guest"},
+			},
+		},
+		{
+			Name:        "Owners as system admins and guests as members",
+			AdminPolicy: AdminPolicySystem,
+			GuestPolicy: GuestPolicyMember,
+			Expected: map[string][3]string{
+				"member":  {"system_user", "team_user", "channel_user"},
+				"admin":   {"system_user", "team_admin team_user", "channel_user"},
+				"owner":   {"system_admin system_user", "team_admin team_user", "channel_user"},
+				"primary": {"system_admin system_user", "team_admin team_user", "channel_user"},
+				"guest":   {"system_user", "team_user", "channel_user"},
+				"single":  {"system_user", "team_user", "channel_user"},
+			},
+		},
+		{
+			Name:        "No admins",
+			AdminPolicy: AdminPolicyNone,
+			GuestPolicy: GuestPolicyGuest,
+			Expected: map[string][3]string{
+				"member":  {"system_user", "team_user", "channel_user"},
+				"admin":   {"system_user", "team_user", "channel_user"},
+				"owner":   {"system_user", "team_user", "channel_user"},
+				"primary": {"system_user", "team_user", "channel_user"},
+				"guest":   {"system_guest", "team_guest", "channel_guest"},
+				"single":  {"system_guest", "team_guest", "channel_guest"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			slackTransformer := NewTransformer("test", log.New())
+			slackTransformer.AdminPolicy = tc.AdminPolicy
+			slackTransformer.GuestPolicy = tc.GuestPolicy
+			slackTransformer.TransformUsers(users)
+
+			for id, expected := range tc.Expected {
+				user := slackTransformer.Intermediate.UsersById[id]
+				user.Memberships = []string{"c1"}
+				line := GetImportLineFromUser(user, "test")
+				assert.Equal(t, expected[0], *line.User.Roles, id)
+				assert.Equal(t, expected[1], *(*line.User.Teams)[0].Roles, id)
+				assert.Equal(t, expected[2], *(*(*line.User.Teams)[0].Channels)[0].Roles, id)
+			}
+		})
+	}
+}
+
+func TestChannelAdmins(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.Intermediate.UsersById = map[string]*IntermediateUser{
+		"m1": {Id: "m1", Username: "creator"},
+		"m2": {Id: "m2", Username: "manager"},
+		"m3": {Id: "m3", Username: "member"},
+	}
+
+	adminsFile, err := os.CreateTemp(t.TempDir(), "channel_admins.csv")
+	require.NoError(t, err)
+	_, err = adminsFile.WriteString("channel_name,username\nchannel-name-1,manager\n")
+	require.NoError(t, err)
+	_, err = adminsFile.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	require.NoError(t, slackTransformer.ParseChannelAdmins(adminsFile))
+
+	slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels([]SlackChannel{
+		{Id: "id1", Name: "channel-name-1", Creator: "m1", Members: []string{"m1", "m2", "m3"}, Type: model.ChannelTypeOpen},
+		{Id: "id2", Name: "channel-name-2", Creator: "m1", Members: []string{"m2", "m3"}, Type: model.ChannelTypeOpen},
+	}, false)
+	require.Len(t, slackTransformer.Intermediate.PublicChannels, 2)
+	assert.Equal(t, []string{"m1", "m2"}, slackTransformer.Intermediate.PublicChannels[0].Admins)
+	assert.Equal(t, []string{}, slackTransformer.Intermediate.PublicChannels[1].Admins)
+
+	slackTransformer.PopulateUserMemberships()
+	assert.Equal(t, []string{"channel-name-1"}, slackTransformer.Intermediate.UsersById["m1"].AdminMemberships)
+	assert.Equal(t, []string{"channel-name-1"}, slackTransformer.Intermediate.UsersById["m2"].AdminMemberships)
+	assert.Equal(t, []string{}, slackTransformer.Intermediate.UsersById["m3"].AdminMemberships)
+
+	line := GetImportLineFromUser(slackTransformer.Intermediate.UsersById["m2"], "test")
+	channels := *(*line.User.Teams)[0].Channels
+	require.Len(t, channels, 2)
+	assert.Equal(t, "channel_admin channel_user", *channels[0].Roles)
+	assert.Equal(t, "channel_user", *channels[1].Roles)
+}
+
+func TestTransformArchivedChannels(t *testing.T) {
+	channels := []SlackChannel{
+		{Id: "id1", Name: "live", Members: []string{"m1", "m2"}, Type: model.ChannelTypeOpen},
+		{Id: "id2", Name: "archived", Members: []string{"m1", "m2"}, IsArchived: true, Type: model.ChannelTypeOpen},
+	}
+
+	t.Run("Archived channels are included and reported", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.ArchivedChannels = ArchivedChannelsSeparateReport
+		slackTransformer.Intermediate.UsersById = map[string]*IntermediateUser{"m1": {}, "m2": {}}
+
+		slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels(channels, false)
+		require.Len(t, slackTransformer.Intermediate.PublicChannels, 2)
+		assert.False(t, slackTransformer.Intermediate.PublicChannels[0].IsArchived)
+		assert.True(t, slackTransformer.Intermediate.PublicChannels[1].IsArchived)
+
+		var b strings.Builder
+		require.NoError(t, slackTransformer.ExportArchivedChannels(&b))
+		assert.JSONEq(t, `[{"team": "test", "name": "archived", "display_name": "archived", "type": "O"}]`, b.String())
+	})
+
+	t.Run("Archived channels are skipped", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.ArchivedChannels = ArchivedChannelsSkip
+		slackTransformer.Intermediate.UsersById = map[string]*IntermediateUser{"m1": {}, "m2": {}}
+
+		result := slackTransformer.TransformChannels(channels, false)
+		require.Len(t, result, 1)
+		assert.Equal(t, "live", result[0].Name)
+	})
+}
+
+func TestTransformUserNames(t *testing.T) {
+	users := []SlackUser{
+		{Id: "id1", Username: "complete", Profile: SlackProfile{FirstName: "Ada", LastName: "Lovelace", DisplayName: "ada", RealName: "Ada Lovelace"}},
+		{Id: "id2", Username: "realname", Profile: SlackProfile{DisplayName: "grace", RealName: "Grace Brewster Hopper", RealNameNormalized: "Grace Brewster Hopper"}},
+		{Id: "id3", Username: "displayname", Profile: SlackProfile{DisplayName: "Linus Torvalds 🐧", DisplayNameNormalized: "Linus Torvalds"}},
+	}
+
+	t.Run("Default policy", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.TransformUsers(users)
+
+		for id, expected := range map[string][3]string{
+			"id1": {"ada", "Ada", "Lovelace"},
+			"id2": {"grace", "Grace", "Brewster Hopper"},
+			"id3": {"Linus Torvalds 🐧", "", ""},
+		} {
+			user := slackTransformer.Intermediate.UsersById[id]
+			assert.Equal(t, expected, [3]string{user.Nickname, user.FirstName, user.LastName}, id)
+		}
+
+		line := GetImportLineFromUser(slackTransformer.Intermediate.UsersById["id1"], "test")
+		assert.Equal(t, "ada", *line.User.Nickname)
+	})
+
+	t.Run("Custom policy", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.NicknameField = NameFieldNone
+		slackTransformer.FullNameFallbackField = NameFieldDisplayNameNormalized
+		slackTransformer.TransformUsers(users)
+
+		for id, expected := range map[string][3]string{
+			"id1": {"", "Ada", "Lovelace"},
+			"id2": {"", "grace", ""},
+			"id3": {"", "Linus", "Torvalds"},
+		} {
+			user := slackTransformer.Intermediate.UsersById[id]
+			assert.Equal(t, expected, [3]string{user.Nickname, user.FirstName, user.LastName}, id)
+		}
+	})
+}
+
+func TestAddPostToThreadsMissingRoot(t *testing.T) {
+	channel := &IntermediateChannel{Type: model.ChannelTypeOpen}
+	threads := map[string]*IntermediatePost{}
+	timestamps := map[int64]bool{}
+
+	reply := &IntermediatePost{CreateAt: 1549307812000}
+	added := AddPostToThreads(SlackPost{TimeStamp: "1549307812.000000", ThreadTS: "1549307811.071000"}, reply, threads, channel, timestamps)
+
+	assert.False(t, added)
+	assert.Empty(t, threads)
+	assert.Empty(t, timestamps)
+}
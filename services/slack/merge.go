@@ -35,6 +35,8 @@ func (t *Transformer) MergeSlackExports(slackExports []*SlackExport) (*SlackExpo
 			result.Users = cloneSlice(slackExport.Users)
 			result.Posts = cloneMap(slackExport.Posts)
 			result.Uploads = cloneMap(slackExport.Uploads)
+			result.ProfileImages = cloneMap(slackExport.ProfileImages)
+			result.Usergroups = cloneSlice(slackExport.Usergroups)
 			continue
 		}
 		// Merge TeamName        string
@@ -81,6 +83,16 @@ func (t *Transformer) MergeSlackExports(slackExports []*SlackExport) (*SlackExpo
 		if err != nil {
 			return nil, err
 		}
+		// Merge ProfileImages   map[string]*zip.File
+		result.ProfileImages, err = mergeUploads(result.ProfileImages, slackExport.ProfileImages)
+		if err != nil {
+			return nil, err
+		}
+		// Merge Usergroups      []SlackUsergroup
+		result.Usergroups, err = mergeUsergroups(result.Usergroups, slackExport.Usergroups)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return result, nil
 }
@@ -188,10 +200,36 @@ func mergeChannel(a, b SlackChannel) (SlackChannel, error) {
 	if !reflect.DeepEqual(a.Topic, b.Topic) {
 		return nothing, errors.Errorf("cannot merge channels with different topics: %v and %v", a.Topic, b.Topic)
 	}
+	// Pins    []SlackPin
+	// Exports from different time periods may know about different pins, so
+	// we keep all of them.
+	pins, err := mergeSlicesWith(a.Pins, b.Pins, func(x SlackPin) string { return x.Id }, func(x, y SlackPin) (SlackPin, error) { return x, nil })
+	if err != nil {
+		return nothing, err
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i].Id < pins[j].Id })
 	// Type    model.ChannelType
 	if a.Type != b.Type {
 		return nothing, errors.Errorf("cannot merge channels with different types: %v and %v", a.Type, b.Type)
 	}
+	// IsArchived bool
+	// A channel may have been archived after an earlier export was made.
+	a.IsArchived = a.IsArchived || b.IsArchived
+	a.Pins = pins
+	// Bookmarks []SlackBookmark
+	// Bookmarks may have been added or edited between exports, so we keep
+	// all of them in their latest version.
+	bookmarks, err := mergeSlicesWith(a.Bookmarks, b.Bookmarks, func(x SlackBookmark) string { return x.Id }, func(x, y SlackBookmark) (SlackBookmark, error) {
+		if y.DateUpdated > x.DateUpdated {
+			return y, nil
+		}
+		return x, nil
+	})
+	if err != nil {
+		return nothing, err
+	}
+	sort.Slice(bookmarks, func(i, j int) bool { return bookmarks[i].Id < bookmarks[j].Id })
+	a.Bookmarks = bookmarks
 	return a, nil
 }
 
@@ -204,17 +242,76 @@ func mergeUser(a, b SlackUser) (SlackUser, error) {
 	if reflect.DeepEqual(a, b) {
 		return a, nil
 	}
+	// Users built from the profiles in messages are only a fallback for
+	// users missing from users.json.
+	if a.FromUserProfile {
+		return b, nil
+	}
+	if b.FromUserProfile {
+		return a, nil
+	}
+	// Exports from different time periods may have different versions of
+	// the profile, so we keep the latest one, including whether the user is
+	// deactivated.
+	if b.Updated > a.Updated {
+		a, b = b, a
+	}
 	// We can modify these since the whole structure was passed by value.
 	if a.Profile.Email == "" {
 		a.Profile.Email = b.Profile.Email
 	}
-	if b.Profile.Email == "" {
-		b.Profile.Email = a.Profile.Email
-	}
-	if reflect.DeepEqual(a, b) {
+	if reflect.DeepEqual(a, withChangingFieldsOf(b, a)) {
 		return a, nil
 	}
-	return SlackUser{}, errors.Errorf("cannot merge users that differ (in other ways than email missing from one of them): %v and %v", a, b)
+	return SlackUser{}, errors.Errorf("cannot merge users that differ (in other ways than their profile changing over time): %v and %v", a, b)
+}
+
+// withChangingFieldsOf returns the user with the fields that change over
+// time, such as the names, title, roles, pictures and timezone, taken from
+// the other user.
+func withChangingFieldsOf(user, other SlackUser) SlackUser {
+	user.Deleted = other.Deleted
+	user.Updated = other.Updated
+	user.TZ = other.TZ
+	user.TZOffset = other.TZOffset
+	user.Locale = other.Locale
+	user.IsAdmin = other.IsAdmin
+	user.IsOwner = other.IsOwner
+	user.IsPrimaryOwner = other.IsPrimaryOwner
+	user.IsRestricted = other.IsRestricted
+	user.IsUltraRestricted = other.IsUltraRestricted
+	user.Profile.Email = other.Profile.Email
+	user.Profile.FirstName = other.Profile.FirstName
+	user.Profile.LastName = other.Profile.LastName
+	user.Profile.Title = other.Profile.Title
+	user.Profile.DisplayName = other.Profile.DisplayName
+	user.Profile.DisplayNameNormalized = other.Profile.DisplayNameNormalized
+	user.Profile.RealName = other.Profile.RealName
+	user.Profile.RealNameNormalized = other.Profile.RealNameNormalized
+	user.Profile.IsCustomImage = other.Profile.IsCustomImage
+	user.Profile.ImageOriginal = other.Profile.ImageOriginal
+	user.Profile.Image512 = other.Profile.Image512
+	return user
+}
+
+// mergeUsergroups merges two slices of SlackUsergroup, using the Id field to
+// determine the identity of each user group. The members of a user group are
+// the union of its members in both exports.
+func mergeUsergroups(a, b []SlackUsergroup) ([]SlackUsergroup, error) {
+	return mergeSlicesWith(a, b, func(x SlackUsergroup) string { return x.Id }, mergeUsergroup)
+}
+func mergeUsergroup(a, b SlackUsergroup) (SlackUsergroup, error) {
+	users, err := mergeSlicesWith(a.Users, b.Users, func(x string) string { return x }, func(x, y string) (string, error) { return x, nil })
+	if err != nil {
+		return SlackUsergroup{}, err
+	}
+	a.Users = nil
+	b.Users = nil
+	if !reflect.DeepEqual(a, b) {
+		return SlackUsergroup{}, errors.Errorf("cannot merge user groups that differ (in other ways than their members): %v and %v", a, b)
+	}
+	a.Users = users
+	return a, nil
 }
 
 // mergePosts merges two maps of []SlackPost, using the TimeStamp field to
@@ -226,24 +323,59 @@ func mergePostSlice(a, b []SlackPost) ([]SlackPost, error) {
 	return mergeSlicesWith(a, b, func(x SlackPost) string { return x.TimeStamp }, mergePost)
 }
 func mergePost(a SlackPost, b SlackPost) (SlackPost, error) {
-	// Check that the posts are equal except for the Original field.
-	aCopy, bCopy := a, b
+	// Check that the posts are equal except for the Original field and the
+	// starring information, which depends on the perspective of the export.
+	aCopy, bCopy := withoutStars(a), withoutStars(b)
 	aCopy.Original = ""
 	bCopy.Original = ""
 	if !reflect.DeepEqual(aCopy, bCopy) {
 		return SlackPost{}, errors.Errorf("cannot merge unequal posts: %v and %v", a, b)
 	}
 	// Check that the Original fields are equivalent, meaning equal except for the
-	// last_read, subscribed, blocks[].block_id and files[].is_starred fields.
+	// last_read, subscribed, is_starred, blocks[].block_id and files[].is_starred fields.
 	originalsAreEquivalent, err := postOriginalEquivalent(a.Original, b.Original)
 	if err != nil {
 		return SlackPost{}, err
 	}
 	if !originalsAreEquivalent {
-		return SlackPost{}, errors.Errorf("cannot merge posts with original JSON that differs in other ways than last_read, subscribed, blocks[].block_id and files[].is_starred: %s and %s", a.Original, b.Original)
+		return SlackPost{}, errors.Errorf("cannot merge posts with original JSON that differs in other ways than last_read, subscribed, is_starred, blocks[].block_id and files[].is_starred: %s and %s", a.Original, b.Original)
+	}
+	// Keep the stars from both exports.
+	starredBy, err := mergeSlicesWith(a.StarredBy, b.StarredBy, func(x string) string { return x }, func(x, y string) (string, error) { return x, nil })
+	if err != nil {
+		return SlackPost{}, err
+	}
+	sort.Strings(starredBy)
+	if len(starredBy) > 0 {
+		a.StarredBy = starredBy
 	}
+	a.IsStarred = a.IsStarred || b.IsStarred
 	return a, nil
 }
+
+// withoutStars returns a copy of the post with all starring information
+// removed. Files are copied so that the original post is not modified.
+func withoutStars(p SlackPost) SlackPost {
+	p.IsStarred = false
+	p.StarredBy = nil
+	if p.File != nil {
+		file := *p.File
+		file.IsStarred = false
+		p.File = &file
+	}
+	if p.Files != nil {
+		files := make([]*SlackFile, len(p.Files))
+		for i, f := range p.Files {
+			if f != nil {
+				file := *f
+				file.IsStarred = false
+				files[i] = &file
+			}
+		}
+		p.Files = files
+	}
+	return p
+}
 func postOriginalEquivalent(a, b string) (bool, error) {
 	var m1, m2 map[string]interface{}
 	if err := json.Unmarshal([]byte(a), &m1); err != nil {
@@ -255,6 +387,7 @@ func postOriginalEquivalent(a, b string) (bool, error) {
 	for _, m := range []map[string]interface{}{m1, m2} {
 		delete(m, "last_read")
 		delete(m, "subscribed")
+		delete(m, "is_starred")
 		if blocks, ok := m["blocks"].([]interface{}); ok {
 			for _, block := range blocks {
 				if blockMap, ok := block.(map[string]interface{}); ok {
@@ -262,6 +395,9 @@ func postOriginalEquivalent(a, b string) (bool, error) {
 				}
 			}
 		}
+		if file, ok := m["file"].(map[string]interface{}); ok {
+			delete(file, "is_starred")
+		}
 		if files, ok := m["files"].([]interface{}); ok {
 			for _, file := range files {
 				if fileMap, ok := file.(map[string]interface{}); ok {
@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePostStars(t *testing.T) {
+	parse := func(owner, data string) SlackPost {
+		posts, err := SlackParsePosts(strings.NewReader(data))
+		require.NoError(t, err)
+		converted, _ := SlackConvertStars(owner, map[string][]SlackPost{"c": posts})
+		return converted["c"][0]
+	}
+
+	a := parse("U1", `[{"type": "message", "ts": "1.0", "text": "hi", "is_starred": true, "files": [{"id": "F1", "is_starred": false}]}]`)
+	b := parse("U2", `[{"type": "message", "ts": "1.0", "text": "hi", "files": [{"id": "F1", "is_starred": true}]}]`)
+	c := parse("U3", `[{"type": "message", "ts": "1.0", "text": "hi", "files": [{"id": "F1"}]}]`)
+
+	merged, err := mergePost(a, b)
+	require.NoError(t, err)
+	merged, err = mergePost(merged, c)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"U1", "U2"}, merged.StarredBy)
+	assert.Equal(t, []string{"U1"}, a.StarredBy)
+	assert.True(t, b.Files[0].IsStarred, "merging should not modify the original posts")
+
+	_, err = mergePost(a, parse("U2", `[{"type": "message", "ts": "1.0", "text": "bye"}]`))
+	require.Error(t, err)
+}
+
+func TestMergeUsersFromDifferentPeriods(t *testing.T) {
+	parse := func(data string) *SlackExport {
+		users, err := SlackParseUsers(strings.NewReader(data))
+		require.NoError(t, err)
+		return &SlackExport{TeamName: "test", Users: users}
+	}
+
+	winter := parse(`[{"id": "U1", "name": "alice", "deleted": true, "updated": 1600000000, "tz": "Europe/Madrid", "tz_offset": 3600, "profile": {"display_name": "Alice", "email": "alice@example.com"}}]`)
+	summer := parse(`[{"id": "U1", "name": "alice", "updated": 1610000000, "tz": "Europe/Madrid", "tz_offset": 7200, "is_admin": true, "profile": {"display_name": "Alice M", "last_name": "Martin", "title": "Lead"}}]`)
+
+	merged, err := NewTransformer("test", log.New()).MergeSlackExports([]*SlackExport{winter, summer})
+	require.NoError(t, err)
+	require.Len(t, merged.Users, 1)
+	user := merged.Users[0]
+	assert.Equal(t, int64(1610000000), user.Updated)
+	assert.Equal(t, 7200, user.TZOffset)
+	assert.Equal(t, "Alice M", user.Profile.DisplayName)
+	assert.Equal(t, "alice@example.com", user.Profile.Email)
+	assert.Equal(t, "Martin", user.Profile.LastName)
+	assert.Equal(t, "Lead", user.Profile.Title)
+	assert.True(t, user.IsAdmin)
+	// the user was reactivated after the earlier export
+	assert.False(t, user.Deleted)
+
+	bot := parse(`[{"id": "U1", "name": "alice", "is_bot": true, "updated": 1620000000}]`)
+	_, err = NewTransformer("test", log.New()).MergeSlackExports([]*SlackExport{winter, bot})
+	require.Error(t, err)
+}
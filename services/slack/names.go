@@ -0,0 +1,75 @@
+package slack
+
+import "strings"
+
+// NameField is a field of the Slack profile that can be used for the names
+// of a Mattermost user.
+type NameField string
+
+const (
+	NameFieldNone                  NameField = "none"
+	NameFieldDisplayName           NameField = "display_name"
+	NameFieldDisplayNameNormalized NameField = "display_name_normalized"
+	NameFieldRealName              NameField = "real_name"
+	NameFieldRealNameNormalized    NameField = "real_name_normalized"
+)
+
+func (f NameField) IsValid() bool {
+	switch f {
+	case NameFieldNone, NameFieldDisplayName, NameFieldDisplayNameNormalized, NameFieldRealName, NameFieldRealNameNormalized:
+		return true
+	}
+	return false
+}
+
+// getNameField returns the value of the given field of the profile. The
+// normalized variants fall back to the original value when missing.
+func getNameField(profile SlackProfile, field NameField) string {
+	var value string
+	switch field {
+	case NameFieldDisplayName:
+		value = profile.DisplayName
+	case NameFieldDisplayNameNormalized:
+		value = profile.DisplayNameNormalized
+		if value == "" {
+			value = profile.DisplayName
+		}
+	case NameFieldRealName:
+		value = profile.RealName
+	case NameFieldRealNameNormalized:
+		value = profile.RealNameNormalized
+		if value == "" {
+			value = profile.RealName
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+// splitFullName splits a full name into a first name and a last name at the
+// first space.
+func splitFullName(name string) (firstName, lastName string) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return fields[0], strings.Join(fields[1:], " ")
+}
+
+// SlackConvertUserNames returns the nickname, first name and last name of the
+// Mattermost user for a Slack profile. The first and last names are taken
+// from the profile and, if both are missing, from the full name fallback
+// field. The nickname is left empty when it would repeat the full name.
+func (t *Transformer) SlackConvertUserNames(profile SlackProfile) (nickname, firstName, lastName string) {
+	firstName = profile.FirstName
+	lastName = profile.LastName
+	if firstName == "" && lastName == "" {
+		firstName, lastName = splitFullName(getNameField(profile, t.FullNameFallbackField))
+	}
+
+	nickname = getNameField(profile, t.NicknameField)
+	if nickname == strings.TrimSpace(firstName+" "+lastName) {
+		nickname = ""
+	}
+
+	return nickname, firstName, lastName
+}
@@ -0,0 +1,114 @@
+package slack
+
+import (
+	"sort"
+)
+
+// OrphanedRepliesMode controls how replies whose root post is missing from
+// the export are imported. This happens with exports of a date range, with
+// deleted root posts and with merged exports.
+type OrphanedRepliesMode string
+
+const (
+	// OrphanedRepliesPlaceholder attaches the replies to a placeholder root
+	// post in place of the missing one.
+	OrphanedRepliesPlaceholder OrphanedRepliesMode = "placeholder"
+	// OrphanedRepliesPromote makes the first reply the root post of the
+	// thread.
+	OrphanedRepliesPromote OrphanedRepliesMode = "promote"
+	// OrphanedRepliesDrop leaves the replies out of the import.
+	OrphanedRepliesDrop OrphanedRepliesMode = "drop"
+)
+
+func (m OrphanedRepliesMode) IsValid() bool {
+	return m == OrphanedRepliesPlaceholder || m == OrphanedRepliesPromote || m == OrphanedRepliesDrop
+}
+
+const orphanedRepliesPlaceholderMessage = "Original message not available in export"
+
+// addPostToThreads adds the post to the threads, handling replies whose root
+// post is missing according to the orphaned replies mode.
+func (t *Transformer) addPostToThreads(original SlackPost, post *IntermediatePost, threads map[string]*IntermediatePost, channel *IntermediateChannel, timestamps map[int64]bool) {
+	if t.Intermediate.OrphanedReplies == nil {
+		t.Intermediate.OrphanedReplies = map[string]int{}
+	}
+
+	if AddPostToThreads(original, post, threads, channel, timestamps) {
+		// replies added to a placeholder or promoted root are orphaned too
+		if original.ThreadTS != original.TimeStamp && t.replacedRoots[threads[original.ThreadTS]] {
+			t.Intermediate.OrphanedReplies[channel.Name]++
+		}
+		t.recordPermalinkTarget(original, post, channel)
+		return
+	}
+	// the deleted root post is already counted as unavailable content, so
+	// the reply silently takes its place
+	if t.deletedRoots[channel.Id+"/"+original.ThreadTS] {
+		AddPostToThreads(SlackPost{TimeStamp: original.ThreadTS, ThreadTS: original.ThreadTS}, post, threads, channel, timestamps)
+		t.recordPermalinkTarget(original, post, channel)
+		return
+	}
+	t.Intermediate.OrphanedReplies[channel.Name]++
+
+	switch t.OrphanedReplies {
+	case OrphanedRepliesDrop:
+		t.Logger.Debugf("Dropping reply %s as the root post of thread %s is missing", original.TimeStamp, original.ThreadTS)
+		return
+	case OrphanedRepliesPromote:
+		// the reply takes the place of the missing root post, and the
+		// following replies are added to it
+		AddPostToThreads(SlackPost{TimeStamp: original.ThreadTS, ThreadTS: original.ThreadTS}, post, threads, channel, timestamps)
+		t.replaceRoot(post)
+		t.recordPermalinkTarget(original, post, channel)
+		return
+	}
+
+	author := post.User
+	if original.ParentUser != "" {
+		parent := t.Intermediate.UsersById[original.ParentUser]
+		if parent == nil {
+			t.CreateIntermediateUser(original.ParentUser)
+			parent = t.Intermediate.UsersById[original.ParentUser]
+		}
+		author = parent.Username
+	}
+	placeholder := &IntermediatePost{
+		User:     author,
+		Channel:  post.Channel,
+		Message:  orphanedRepliesPlaceholderMessage,
+		CreateAt: SlackConvertTimeStamp(original.ThreadTS),
+	}
+	AddPostToThreads(SlackPost{TimeStamp: original.ThreadTS, ThreadTS: original.ThreadTS}, placeholder, threads, channel, timestamps)
+	t.replaceRoot(placeholder)
+	AddPostToThreads(original, post, threads, channel, timestamps)
+	t.recordPermalinkTarget(original, post, channel)
+}
+
+// replaceRoot records that the post stands in for a missing root post.
+func (t *Transformer) replaceRoot(post *IntermediatePost) {
+	if t.replacedRoots == nil {
+		t.replacedRoots = map[*IntermediatePost]bool{}
+	}
+	t.replacedRoots[post] = true
+}
+
+// logOrphanedReplies logs how many replies of each channel had their root
+// post missing.
+func (t *Transformer) logOrphanedReplies() {
+	if len(t.Intermediate.OrphanedReplies) == 0 {
+		return
+	}
+
+	channelNames := []string{}
+	total := 0
+	for channelName, count := range t.Intermediate.OrphanedReplies {
+		channelNames = append(channelNames, channelName)
+		total += count
+	}
+	sort.Strings(channelNames)
+
+	t.Logger.Warnf("Found %d replies whose root post is missing from the export, handled with the %q policy", total, t.OrphanedReplies)
+	for _, channelName := range channelNames {
+		t.Logger.Warnf("  %s: %d orphaned replies", channelName, t.Intermediate.OrphanedReplies[channelName])
+	}
+}
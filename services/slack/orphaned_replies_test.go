@@ -0,0 +1,56 @@
+package slack
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformOrphanedReplies(t *testing.T) {
+	posts := []SlackPost{
+		{Type: "message", User: "U2", ParentUser: "U1", Text: "First reply", TimeStamp: "1600000001.000100", ThreadTS: "1600000000.000100"},
+		{Type: "message", User: "U1", Text: "Second reply", TimeStamp: "1600000002.000100", ThreadTS: "1600000000.000100"},
+		{Type: "message", User: "U1", Text: "Unrelated", TimeStamp: "1600000003.000100"},
+	}
+
+	transform := func(mode OrphanedRepliesMode) (*Transformer, []*IntermediatePost) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.OrphanedReplies = mode
+		return slackTransformer, transformTestPosts(t, slackTransformer, map[string][]SlackPost{"general": posts})
+	}
+
+	t.Run("Placeholder", func(t *testing.T) {
+		slackTransformer, result := transform(OrphanedRepliesPlaceholder)
+		require.Len(t, result, 2)
+
+		root := result[0]
+		assert.Equal(t, orphanedRepliesPlaceholderMessage, root.Message)
+		assert.Equal(t, "alice", root.User)
+		assert.Equal(t, SlackConvertTimeStamp("1600000000.000100"), root.CreateAt)
+		require.Len(t, root.Replies, 2)
+		assert.Equal(t, "First reply", root.Replies[0].Message)
+		assert.Equal(t, "Second reply", root.Replies[1].Message)
+		assert.Equal(t, map[string]int{"general": 2}, slackTransformer.Intermediate.OrphanedReplies)
+	})
+
+	t.Run("Promote", func(t *testing.T) {
+		slackTransformer, result := transform(OrphanedRepliesPromote)
+		require.Len(t, result, 2)
+
+		root := result[0]
+		assert.Equal(t, "First reply", root.Message)
+		assert.Equal(t, "bob", root.User)
+		require.Len(t, root.Replies, 1)
+		assert.Equal(t, "Second reply", root.Replies[0].Message)
+		assert.Equal(t, map[string]int{"general": 2}, slackTransformer.Intermediate.OrphanedReplies)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		slackTransformer, result := transform(OrphanedRepliesDrop)
+		require.Len(t, result, 1)
+		assert.Equal(t, "Unrelated", result[0].Message)
+		assert.Equal(t, map[string]int{"general": 2}, slackTransformer.Intermediate.OrphanedReplies)
+	})
+}
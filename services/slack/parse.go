@@ -14,40 +14,110 @@ import (
 )
 
 type SlackChannel struct {
-	Id        string          `json:"id"`
-	Name      string          `json:"name"`
-	Creator   string          `json:"creator"`
-	Members   []string        `json:"members"`
-	Purpose   SlackChannelSub `json:"purpose"`
-	Topic     SlackChannelSub `json:"topic"`
-	IsPrivate bool            `json:"is_private"`
-	Type      model.ChannelType
+	Id         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Creator    string                 `json:"creator"`
+	Members    []string               `json:"members"`
+	Purpose    SlackChannelSub        `json:"purpose"`
+	Topic      SlackChannelSub        `json:"topic"`
+	IsPrivate  bool                   `json:"is_private"`
+	IsArchived bool                   `json:"is_archived"`
+	Created    int64                  `json:"created"`
+	Pins       []SlackPin             `json:"pins"`
+	Bookmarks  []SlackBookmark        `json:"bookmarks"`
+	Properties SlackChannelProperties `json:"properties"`
+	Type       model.ChannelType
+}
+
+type SlackChannelProperties struct {
+	// Canvas is the canvas of the channel, if it has one
+	Canvas *SlackChannelCanvas `json:"canvas"`
+}
+
+type SlackChannelCanvas struct {
+	FileId  string `json:"file_id"`
+	IsEmpty bool   `json:"is_empty"`
 }
 
 type SlackChannelSub struct {
 	Value string `json:"value"`
 }
 
+// SlackPin is an entry of the pins array of a channel. Its Id is the
+// timestamp of the pinned message.
+type SlackPin struct {
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	User    string `json:"user"`
+}
+
 type SlackProfile struct {
 	BotID     string `json:"bot_id"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
 	Title     string `json:"title"`
+
+	DisplayName           string `json:"display_name"`
+	DisplayNameNormalized string `json:"display_name_normalized"`
+	RealName              string `json:"real_name"`
+	RealNameNormalized    string `json:"real_name_normalized"`
+
+	IsCustomImage bool   `json:"is_custom_image"`
+	ImageOriginal string `json:"image_original"`
+	Image512      string `json:"image_512"`
 }
 
 type SlackUser struct {
 	Id       string       `json:"id"`
 	Username string       `json:"name"`
 	IsBot    bool         `json:"is_bot"`
+	Deleted  bool         `json:"deleted"`
+	Updated  int64        `json:"updated"`
 	Profile  SlackProfile `json:"profile"`
+	TZ       string       `json:"tz"`
+	TZOffset int          `json:"tz_offset"`
+	Locale   string       `json:"locale"`
+	TeamId   string       `json:"team_id"`
+	// EnterpriseUser is set for users of Enterprise Grid organisations
+	EnterpriseUser *SlackEnterpriseUser `json:"enterprise_user"`
+
+	IsAdmin           bool `json:"is_admin"`
+	IsOwner           bool `json:"is_owner"`
+	IsPrimaryOwner    bool `json:"is_primary_owner"`
+	IsRestricted      bool `json:"is_restricted"`
+	IsUltraRestricted bool `json:"is_ultra_restricted"`
+
+	// FromUserProfile is set for users missing from users.json, that were
+	// built from the profile embedded in their posts
+	FromUserProfile bool `json:"-"`
+}
+
+type SlackEnterpriseUser struct {
+	Id           string   `json:"id"`
+	EnterpriseId string   `json:"enterprise_id"`
+	Teams        []string `json:"teams"`
 }
 
 type SlackFile struct {
-	Id          string `json:"id"`
-	Name        string `json:"name"`
-	Size        int64  `json:"size"`
-	DownloadURL string `json:"url_private_download"`
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Size         int64  `json:"size"`
+	DownloadURL  string `json:"url_private_download"`
+	IsStarred    bool   `json:"is_starred"`
+	Title        string `json:"title"`
+	Filetype     string `json:"filetype"`
+	Mimetype     string `json:"mimetype"`
+	User         string `json:"user"`
+	Mode         string `json:"mode"`
+	Preview      string `json:"preview"`
+	PlainText    string `json:"plain_text"`
+	IsExternal   bool   `json:"is_external"`
+	ExternalType string `json:"external_type"`
+	URLPrivate   string `json:"url_private"`
+	Permalink    string `json:"permalink"`
+	FileAccess   string `json:"file_access"`
 }
 
 type SlackReaction struct {
@@ -64,6 +134,7 @@ type SlackPost struct {
 	Text        string                   `json:"text"`
 	TimeStamp   string                   `json:"ts"`
 	ThreadTS    string                   `json:"thread_ts"`
+	ParentUser  string                   `json:"parent_user_id"` // Slack ID of the author of the root post of the thread
 	Type        string                   `json:"type"`
 	SubType     string                   `json:"subtype"`
 	Comment     *SlackComment            `json:"comment"`
@@ -72,6 +143,58 @@ type SlackPost struct {
 	Files       []*SlackFile             `json:"files"`
 	Attachments []*model.SlackAttachment `json:"attachments"`
 	Reactions   *[]SlackReaction         `json:"reactions"`
+	Edited      *SlackEdited             `json:"edited"`
+	PinnedTo    []string                 `json:"pinned_to"`
+	IsStarred   bool                     `json:"is_starred"`
+	StarredBy   []string                 `json:"-"` // Slack IDs of the users that starred the post
+	Blocks      []SlackBlock             `json:"blocks"`
+	UserProfile *SlackUserProfile        `json:"user_profile"`
+	BotProfile  *SlackBotProfile         `json:"bot_profile"`
+	Icons       *SlackIcons              `json:"icons"`
+	// Topic, Purpose, Name, OldName and Inviter describe system events
+	Topic   string `json:"topic"`
+	Purpose string `json:"purpose"`
+	Name    string `json:"name"`
+	OldName string `json:"old_name"`
+	Inviter string `json:"inviter"`
+	// TextFromBlocks is set when Text has been rendered from the blocks of
+	// the post, so it is already Markdown
+	TextFromBlocks bool `json:"-"`
+}
+
+type SlackEdited struct {
+	User      string `json:"user"`
+	TimeStamp string `json:"ts"`
+}
+
+// EditAt returns the time of the last edit of the post in milliseconds, or 0
+// if the post has never been edited.
+func (p *SlackPost) EditAt() int64 {
+	if p.Edited == nil || p.Edited.TimeStamp == "" {
+		return 0
+	}
+	return SlackConvertTimeStamp(p.Edited.TimeStamp)
+}
+
+func (p *SlackPost) IsPinned() bool {
+	return len(p.PinnedTo) > 0
+}
+
+// HasStar returns true if the post, or any of its files, is starred by the
+// user the export belongs to.
+func (p *SlackPost) HasStar() bool {
+	if p.IsStarred {
+		return true
+	}
+	if p.File != nil && p.File.IsStarred {
+		return true
+	}
+	for _, file := range p.Files {
+		if file != nil && file.IsStarred {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *SlackPost) IsPlainMessage() bool {
@@ -121,6 +244,8 @@ type SlackExport struct {
 	Users           []SlackUser
 	Posts           map[string][]SlackPost
 	Uploads         map[string]*zip.File
+	ProfileImages   map[string]*zip.File
+	Usergroups      []SlackUsergroup
 }
 
 func SlackParseUsers(data io.Reader) ([]SlackUser, error) {
@@ -224,6 +349,37 @@ func SlackConvertChannelMentions(channels []SlackChannel, posts map[string][]Sla
 	return posts
 }
 
+// SlackConvertStars records the given user as having starred every post that
+// is starred in the export. It returns the posts and the number of starred
+// posts found.
+func SlackConvertStars(starringUser string, posts map[string][]SlackPost) (map[string][]SlackPost, int) {
+	count := 0
+	for channelName, channelPosts := range posts {
+		for postIdx := range channelPosts {
+			post := &posts[channelName][postIdx]
+			if !post.HasStar() {
+				continue
+			}
+			count++
+			if starringUser == "" || containsString(post.StarredBy, starringUser) {
+				continue
+			}
+			post.StarredBy = append(post.StarredBy, starringUser)
+		}
+	}
+
+	return posts, count
+}
+
+func containsString(slice []string, s string) bool {
+	for _, x := range slice {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
 func SlackConvertPostsMarkup(posts map[string][]SlackPost) map[string][]SlackPost {
 	regexReplaceAllString := []struct {
 		regex *regexp.Regexp
@@ -272,6 +428,9 @@ func SlackConvertPostsMarkup(posts map[string][]SlackPost) map[string][]SlackPos
 
 	for channelName, channelPosts := range posts {
 		for postIdx, post := range channelPosts {
+			if post.TextFromBlocks {
+				continue
+			}
 			result := post.Text
 
 			for _, rule := range regexReplaceAllString {
@@ -292,6 +451,7 @@ func (t *Transformer) ParseSlackExportFile(zipReader *zip.Reader, skipConvertPos
 	slackExport := SlackExport{TeamName: t.TeamName}
 	slackExport.Posts = make(map[string][]SlackPost)
 	slackExport.Uploads = make(map[string]*zip.File)
+	slackExport.ProfileImages = make(map[string]*zip.File)
 
 	for _, file := range zipReader.File {
 		reader, err := file.Open()
@@ -313,6 +473,11 @@ func (t *Transformer) ParseSlackExportFile(zipReader *zip.Reader, skipConvertPos
 			slackExport.Channels = append(slackExport.Channels, slackExport.GroupChannels...)
 		} else if file.Name == "users.json" {
 			slackExport.Users, _ = SlackParseUsers(reader)
+		} else if file.Name == "usergroups.json" {
+			slackExport.Usergroups, err = SlackParseUsergroups(reader)
+			if err != nil {
+				t.Logger.WithError(err).Warn("Failed to parse the user groups of the export")
+			}
 		} else {
 			spl := strings.Split(file.Name, "/")
 			if len(spl) == 2 && strings.HasSuffix(spl[1], ".json") {
@@ -325,15 +490,36 @@ func (t *Transformer) ParseSlackExportFile(zipReader *zip.Reader, skipConvertPos
 				}
 			} else if len(spl) == 3 && spl[0] == "__uploads" {
 				slackExport.Uploads[spl[1]] = file
+			} else if len(spl) == 3 && spl[0] == "__avatars" {
+				slackExport.ProfileImages[spl[1]] = file
 			}
 		}
 	}
 
+	usersCount := len(slackExport.Users)
+	slackExport.Users = SlackHarvestUserProfiles(slackExport.Users, slackExport.Posts)
+	if harvestedCount := len(slackExport.Users) - usersCount; harvestedCount > 0 {
+		t.Logger.Infof("Found %d users missing from users.json, built from the profiles in their messages", harvestedCount)
+	}
+
+	slackExport.Usergroups = mergeSuppliedUsergroups(slackExport.Usergroups, t.Usergroups)
+	for _, channels := range [][]SlackChannel{slackExport.Channels, slackExport.PublicChannels, slackExport.PrivateChannels, slackExport.GroupChannels, slackExport.DirectChannels} {
+		addSuppliedBookmarks(channels, t.Bookmarks)
+	}
+
+	var starredCount int
+	slackExport.Posts, starredCount = SlackConvertStars(t.ExportOwner, slackExport.Posts)
+	if starredCount > 0 && t.ExportOwner == "" {
+		t.Logger.Warnf("Found %d starred posts, but the owner of the export is unknown so they won't be flagged", starredCount)
+	}
+
 	if !skipConvertPosts {
 		t.Logger.Info("Converting post mentions and markup")
 		start := time.Now()
+		slackExport.Posts = SlackConvertBlocks(slackExport.Users, slackExport.Channels, slackExport.Posts)
 		slackExport.Posts = SlackConvertUserMentions(slackExport.Users, slackExport.Posts)
 		slackExport.Posts = SlackConvertChannelMentions(slackExport.Channels, slackExport.Posts)
+		slackExport.Posts = SlackConvertUsergroupMentions(slackExport.Usergroups, slackExport.Posts)
 		slackExport.Posts = SlackConvertPostsMarkup(slackExport.Posts)
 		elapsed := time.Since(start)
 		t.Logger.Debug("Converting mentions finished (%s)", elapsed)
@@ -0,0 +1,145 @@
+package slack
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// permalinkRegexp matches the permalinks of Slack messages, such as
+// https://example.slack.com/archives/C0123/p1600000000123456, capturing the
+// channel ID and the digits of the message timestamp.
+var permalinkRegexp = regexp.MustCompile(`https?://(?:[a-zA-Z0-9-]+\.)*slack\.com/archives/([A-Z0-9]+)/p(\d{10})(\d{6})(?:\?[^\s<>|()\[\]]*)?`)
+
+// permalinkReferenceRegexp matches the permalinks along with the Markdown or
+// Slack link that wraps them, if any, so the whole link is replaced.
+var permalinkReferenceRegexp = regexp.MustCompile(`\[[^\[\]\n]*\]\(` + permalinkRegexp.String() + `\)|<` + permalinkRegexp.String() + `(?:\|[^<>\n]*)?>|` + permalinkRegexp.String())
+
+const permalinkTimeFormat = "2006-01-02 15:04 MST"
+
+// permalinkTarget is a message of the export that permalinks can point to.
+type permalinkTarget struct {
+	User     string
+	CreateAt int64
+	Excerpt  string
+}
+
+// recordPermalinkTarget records the post as the message with the timestamp of
+// the original post in the channel.
+func (t *Transformer) recordPermalinkTarget(original SlackPost, post *IntermediatePost, channel *IntermediateChannel) {
+	if t.permalinkTargets == nil {
+		t.permalinkTargets = map[string]permalinkTarget{}
+	}
+	t.permalinkTargets[channel.Id+"/"+original.TimeStamp] = permalinkTarget{
+		User:     post.User,
+		CreateAt: post.CreateAt,
+		Excerpt:  threadExcerpt(post.Message),
+	}
+}
+
+// permalinkLabel returns the text of the link wrapping the permalink, unless
+// it is the permalink itself.
+func permalinkLabel(link, url string) string {
+	label := ""
+	switch {
+	case strings.HasPrefix(link, "["):
+		label = link[1:strings.Index(link, "](")]
+	case strings.HasPrefix(link, "<") && strings.Contains(link, "|"):
+		label = link[strings.Index(link, "|")+1 : len(link)-1]
+	}
+	label = strings.TrimSpace(label)
+	if label == url {
+		return ""
+	}
+	return label
+}
+
+// convertPermalink returns the reference to the message a Slack permalink
+// points to, or false if the channel of the message is not imported. The
+// reference names the channel and the time of the message, and quotes the
+// message when it is found in the export.
+func convertPermalink(link string, channelsById map[string]*IntermediateChannel, targets map[string]permalinkTarget) (string, bool) {
+	matches := permalinkRegexp.FindStringSubmatch(link)
+	if matches == nil {
+		return link, false
+	}
+	channel, ok := channelsById[matches[1]]
+	if !ok {
+		return link, false
+	}
+
+	var place string
+	switch channel.Type {
+	case model.ChannelTypeDirect:
+		place = "a direct message"
+	case model.ChannelTypeGroup:
+		place = "a group message"
+	default:
+		place = "~" + channel.Name
+	}
+
+	timestamp := matches[2] + "." + matches[3]
+	var reference string
+	if target, ok := targets[channel.Id+"/"+timestamp]; ok {
+		reference = "message from @" + target.User + " in " + place + " on " + formatPermalinkTime(target.CreateAt)
+		if target.Excerpt != "" {
+			reference += ": “" + target.Excerpt + "”"
+		}
+	} else {
+		reference = "message in " + place + " on " + formatPermalinkTime(SlackConvertTimeStamp(timestamp))
+	}
+
+	if label := permalinkLabel(link, matches[0]); label != "" {
+		return label + " (" + reference + ")", true
+	}
+	return reference, true
+}
+
+func formatPermalinkTime(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format(permalinkTimeFormat)
+}
+
+// rewritePermalinks replaces the permalinks to Slack messages of the imported
+// channels with references to the channel and the message, as they won't
+// resolve once the Slack workspace is gone. Permalinks to other channels are
+// kept.
+func (t *Transformer) rewritePermalinks() {
+	channelsById := map[string]*IntermediateChannel{}
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels, t.Intermediate.GroupChannels, t.Intermediate.DirectChannels} {
+		for _, channel := range channels {
+			channelsById[channel.Id] = channel
+		}
+	}
+
+	rewritten, kept := 0, 0
+	rewrite := func(post *IntermediatePost) {
+		if !strings.Contains(post.Message, "slack.com/archives/") {
+			return
+		}
+		post.Message = permalinkReferenceRegexp.ReplaceAllStringFunc(post.Message, func(link string) string {
+			reference, ok := convertPermalink(link, channelsById, t.permalinkTargets)
+			if !ok {
+				kept++
+				return link
+			}
+			rewritten++
+			return reference
+		})
+	}
+
+	for _, post := range t.Intermediate.Posts {
+		rewrite(post)
+		for _, reply := range post.Replies {
+			rewrite(reply)
+		}
+	}
+
+	if rewritten > 0 {
+		t.Logger.Infof("Rewrote %d permalinks to Slack messages", rewritten)
+	}
+	if kept > 0 {
+		t.Logger.Warnf("Kept %d permalinks to Slack messages of channels that are not imported", kept)
+	}
+}
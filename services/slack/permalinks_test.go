@@ -0,0 +1,41 @@
+package slack
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewritePermalinks(t *testing.T) {
+	posts := map[string][]SlackPost{
+		"deploys": {
+			{Type: "message", User: "U1", Text: "Deploy is starting\nDetails follow", TimeStamp: "1600000000.123456"},
+			{Type: "message", User: "U2", Text: "Same as [last time](https://example.slack.com/archives/C1/p1600000000123456)", TimeStamp: "1600000100.000100"},
+		},
+		"general": {
+			{Type: "message", User: "U2", Text: "See https://example.slack.com/archives/C1/p1600000000123456?thread_ts=1600000000.123456&cid=C1 for the deploy", TimeStamp: "1600000200.000100"},
+			{Type: "message", User: "U2", Text: "And <https://example.slack.com/archives/C1/p1500000000000100>", TimeStamp: "1600000300.000100"},
+			{Type: "message", User: "U2", Text: "Elsewhere https://example.slack.com/archives/C9/p1600000000123456", TimeStamp: "1600000400.000100"},
+			{Type: "message", User: "U2", Text: "Not Slack https://notslack.com/archives/C1/p1600000000123456", TimeStamp: "1600000500.000100"},
+		},
+	}
+	result := transformTestPosts(t, NewTransformer("test", log.New()), posts)
+	require.Len(t, result, 6)
+
+	assert.Equal(t, "Same as last time (message from @alice in ~deploys on 2020-09-13 12:26 UTC: “Deploy is starting”)", result[1].Message)
+	assert.Equal(t, "See message from @alice in ~deploys on 2020-09-13 12:26 UTC: “Deploy is starting” for the deploy", result[2].Message)
+	assert.Equal(t, "And message in ~deploys on 2017-07-14 02:40 UTC", result[3].Message)
+	assert.Equal(t, "Elsewhere https://example.slack.com/archives/C9/p1600000000123456", result[4].Message)
+	assert.Equal(t, "Not Slack https://notslack.com/archives/C1/p1600000000123456", result[5].Message)
+}
+
+func TestPermalinkLabel(t *testing.T) {
+	url := "https://example.slack.com/archives/C1/p1600000000123456"
+	assert.Equal(t, "this", permalinkLabel("[this]("+url+")", url))
+	assert.Equal(t, "this", permalinkLabel("<"+url+"|this>", url))
+	assert.Equal(t, "", permalinkLabel("["+url+"]("+url+")", url))
+	assert.Equal(t, "", permalinkLabel("<"+url+">", url))
+	assert.Equal(t, "", permalinkLabel(url, url))
+}
@@ -31,6 +31,13 @@ func (t *Transformer) checkForRequiredFile(zipReader *zip.Reader, fileName strin
 }
 
 func (t *Transformer) Precheck(zipReader *zip.Reader) bool {
+	// Enterprise Grid exports have the organisation's users at the root and
+	// a directory with the channels of each workspace
+	if workspaces := GridWorkspaceNames(zipReader); len(workspaces) > 0 {
+		t.Logger.Infof("Found an Enterprise Grid export with workspaces %s", strings.Join(workspaces, ", "))
+		return t.checkForRequiredFile(zipReader, "users.json")
+	}
+
 	requiredFiles := []string{
 		"channels.json",
 		"integration_logs.json",
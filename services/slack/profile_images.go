@@ -0,0 +1,105 @@
+package slack
+
+import (
+	"archive/zip"
+	"io"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+const profileImagesInternal = "profile_images"
+
+// getProfileImageURL returns the URL of the user's custom profile image, or an
+// empty string if the user has Slack's default one.
+func getProfileImageURL(profile SlackProfile) string {
+	if profile.ImageOriginal != "" {
+		return profile.ImageOriginal
+	}
+	if profile.IsCustomImage {
+		return profile.Image512
+	}
+	return ""
+}
+
+func getProfileImagePath(userId, name string) string {
+	n := makeAlphaNum(name, '.', '-', '_')
+	p := path.Join(attachmentsInternal, profileImagesInternal, userId, n)
+	return norm.NFC.String(p)
+}
+
+func extractProfileImage(zipFile *zip.File, userId, attachmentsDir string) (string, error) {
+	zipFileReader, err := zipFile.Open()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open profile image from zipfile for user %s", userId)
+	}
+	defer zipFileReader.Close()
+
+	destFilePath := getProfileImagePath(userId, path.Base(zipFile.Name))
+	fullFilePath := path.Join(attachmentsDir, destFilePath)
+	if err = createDirectoryForFile(fullFilePath); err != nil {
+		return "", err
+	}
+	destFile, err := os.Create(fullFilePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create profile image for user %s in the attachments directory", userId)
+	}
+	defer destFile.Close()
+
+	if _, err = io.Copy(destFile, zipFileReader); err != nil {
+		return "", errors.Wrapf(err, "failed to create profile image for user %s in the attachments directory", userId)
+	}
+
+	return destFilePath, nil
+}
+
+func downloadProfileImage(imageURL, userId, attachmentsDir string) (string, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid profile image URL for user %s", userId)
+	}
+
+	destFilePath := getProfileImagePath(userId, path.Base(u.Path))
+	fullFilePath := path.Join(attachmentsDir, destFilePath)
+	if err = createDirectoryForFile(fullFilePath); err != nil {
+		return "", err
+	}
+
+	// the size of profile images is not part of the export
+	if err = downloadInto(fullFilePath, imageURL, -1); err != nil {
+		return "", err
+	}
+
+	return destFilePath, nil
+}
+
+// TransformProfileImages adds profile images to the intermediate users, taking
+// them from the export if present or downloading them if allowed.
+func (t *Transformer) TransformProfileImages(slackExport *SlackExport, attachmentsDir string, allowDownload bool) {
+	t.Logger.Info("Transforming profile images")
+
+	for _, slackUser := range slackExport.Users {
+		userId := slackUser.Id
+		if slackUser.IsBot {
+			userId = slackUser.Profile.BotID
+		}
+		user, ok := t.Intermediate.UsersById[userId]
+		if !ok {
+			continue
+		}
+
+		var err error
+		if zipFile, ok := slackExport.ProfileImages[slackUser.Id]; ok {
+			user.ProfileImage, err = extractProfileImage(zipFile, slackUser.Id, attachmentsDir)
+		} else if imageURL := getProfileImageURL(slackUser.Profile); imageURL != "" && allowDownload {
+			t.Logger.Debugf("Downloading profile image of user %s", user.Username)
+			user.ProfileImage, err = downloadProfileImage(imageURL, slackUser.Id, attachmentsDir)
+		}
+		if err != nil {
+			t.Logger.WithError(err).Warnf("Failed to add the profile image of user %s", user.Username)
+		}
+	}
+}
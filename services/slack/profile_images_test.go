@@ -0,0 +1,50 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformProfileImages(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	zipWriter := zip.NewWriter(buf)
+	w, err := zipWriter.Create("__avatars/U1/avatar.png")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("image data"))
+	require.NoError(t, err)
+	require.NoError(t, zipWriter.Close())
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	slackTransformer := NewTransformer("test", log.New())
+	slackExport, err := slackTransformer.ParseSlackExportFile(zipReader, true)
+	require.NoError(t, err)
+	slackExport.Users = []SlackUser{
+		{Id: "U1", Username: "custom"},
+		{Id: "U2", Username: "default"},
+		{Id: "U3", Username: "remote", Profile: SlackProfile{ImageOriginal: "https://example.com/avatar_original.jpg"}},
+	}
+	slackTransformer.TransformUsers(slackExport.Users)
+
+	attachmentsDir := t.TempDir()
+	slackTransformer.TransformProfileImages(slackExport, attachmentsDir, false)
+
+	assert.Equal(t, "bulk-export-attachments/profile_images/U1/avatar.png", slackTransformer.Intermediate.UsersById["U1"].ProfileImage)
+	data, err := os.ReadFile(filepath.Join(attachmentsDir, slackTransformer.Intermediate.UsersById["U1"].ProfileImage))
+	require.NoError(t, err)
+	assert.Equal(t, "image data", string(data))
+	assert.Empty(t, slackTransformer.Intermediate.UsersById["U2"].ProfileImage)
+	// downloads are not allowed
+	assert.Empty(t, slackTransformer.Intermediate.UsersById["U3"].ProfileImage)
+
+	line := GetImportLineFromUser(slackTransformer.Intermediate.UsersById["U1"], "test")
+	require.NotNil(t, line.User.ProfileImage)
+	assert.Equal(t, "bulk-export-attachments/profile_images/U1/avatar.png", *line.User.ProfileImage)
+}
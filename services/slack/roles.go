@@ -0,0 +1,72 @@
+package slack
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// AdminPolicy controls which Mattermost roles Slack workspace admins and
+// owners get.
+type AdminPolicy string
+
+const (
+	// AdminPolicyNone imports admins and owners as regular users.
+	AdminPolicyNone AdminPolicy = "none"
+	// AdminPolicyTeam makes admins and owners team admins.
+	AdminPolicyTeam AdminPolicy = "team"
+	// AdminPolicySystem makes admins and owners team admins, and owners
+	// system admins as well.
+	AdminPolicySystem AdminPolicy = "system"
+)
+
+func (p AdminPolicy) IsValid() bool {
+	return p == AdminPolicyNone || p == AdminPolicyTeam || p == AdminPolicySystem
+}
+
+// GuestPolicy controls how Slack multi-channel and single-channel guests are
+// imported.
+type GuestPolicy string
+
+const (
+	// GuestPolicyGuest imports guests as Mattermost guest accounts.
+	GuestPolicyGuest GuestPolicy = "guest"
+	// GuestPolicyMember imports guests as regular users.
+	GuestPolicyMember GuestPolicy = "member"
+)
+
+func (p GuestPolicy) IsValid() bool {
+	return p == GuestPolicyGuest || p == GuestPolicyMember
+}
+
+func joinRoles(roles ...string) string {
+	return strings.Join(roles, " ")
+}
+
+// SlackConvertUserRoles returns the system, team and channel roles for a
+// Slack user according to the admin and guest policies of the transformer.
+func (t *Transformer) SlackConvertUserRoles(user SlackUser) (systemRoles, teamRoles, channelRoles string) {
+	if (user.IsRestricted || user.IsUltraRestricted) && t.GuestPolicy != GuestPolicyMember {
+		return model.SystemGuestRoleId, model.TeamGuestRoleId, model.ChannelGuestRoleId
+	}
+
+	systemRoles = model.SystemUserRoleId
+	teamRoles = model.TeamUserRoleId
+	channelRoles = model.ChannelUserRoleId
+
+	isOwner := user.IsOwner || user.IsPrimaryOwner
+	switch t.AdminPolicy {
+	case AdminPolicyNone:
+	case AdminPolicySystem:
+		if isOwner {
+			systemRoles = joinRoles(model.SystemAdminRoleId, model.SystemUserRoleId)
+		}
+		fallthrough
+	default:
+		if isOwner || user.IsAdmin {
+			teamRoles = joinRoles(model.TeamAdminRoleId, model.TeamUserRoleId)
+		}
+	}
+
+	return systemRoles, teamRoles, channelRoles
+}
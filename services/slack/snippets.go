@@ -0,0 +1,181 @@
+package slack
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// snippetLanguages maps the Slack snippet types whose name is not a language
+// known to Mattermost's code highlighting.
+var snippetLanguages = map[string]string{
+	"auto":  "",
+	"plain": "",
+	"text":  "",
+	"shell": "bash",
+}
+
+// IsSnippet returns true if the file is a Slack snippet, whose content is
+// code or text.
+func (f *SlackFile) IsSnippet() bool {
+	return f.Mode == "snippet"
+}
+
+// IsSlackPost returns true if the file is a long-form Slack post.
+func (f *SlackFile) IsSlackPost() bool {
+	return f.Mode == "post" || f.Mode == "space"
+}
+
+// isInlined returns true if the content of the file is added to the message
+// it is shared in.
+func (f *SlackFile) isInlined() bool {
+	return (f.IsSnippet() || f.IsSlackPost()) && !f.IsCanvas()
+}
+
+func (p *SlackPost) inlinedFiles() []*SlackFile {
+	files := []*SlackFile{}
+	if p.File != nil && p.File.isInlined() {
+		files = append(files, p.File)
+	}
+	for _, file := range p.Files {
+		if file.isInlined() {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// codeFence returns a fence longer than any run of backticks in the content.
+func codeFence(content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fence
+}
+
+// readUpload returns the content of the file from the export, if it is there.
+func readUpload(file *SlackFile, slackExport *SlackExport) (string, bool) {
+	zipFile, ok := slackExport.Uploads[file.Id]
+	if !ok {
+		return "", false
+	}
+	reader, err := zipFile.Open()
+	if err != nil {
+		return "", false
+	}
+	defer reader.Close()
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// SlackConvertSnippet renders the snippet as a fenced code block in the
+// language of the snippet.
+func SlackConvertSnippet(file *SlackFile, content string) string {
+	language, ok := snippetLanguages[file.Filetype]
+	if !ok {
+		language = file.Filetype
+	}
+	content = strings.TrimRight(content, "\n")
+	fence := codeFence(content)
+
+	message := fence + language + "\n" + content + "\n" + fence
+	if file.Title != "" && file.Title != file.Name {
+		message = "**" + file.Title + "**\n" + message
+	}
+	return message
+}
+
+// inlinedFileContent returns the Markdown content of a snippet or Slack post.
+// The file in the export is preferred, as the text in the message may be
+// shortened.
+func (t *Transformer) inlinedFileContent(file *SlackFile, slackExport *SlackExport) string {
+	content, ok := readUpload(file, slackExport)
+	if file.IsSnippet() {
+		if !ok {
+			content = stringOrDefault(file.PlainText, file.Preview)
+		}
+		if content == "" {
+			return ""
+		}
+		return SlackConvertSnippet(file, content)
+	}
+
+	if ok {
+		converted, err := SlackConvertCanvas(strings.NewReader(content))
+		if err != nil {
+			t.Logger.WithError(err).Warnf("Failed to convert Slack post %s. Its text will be used instead.", file.Id)
+			ok = false
+		}
+		content = converted
+	}
+	if !ok {
+		content = stringOrDefault(file.PlainText, file.Preview)
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+	if file.Title != "" {
+		content = "#### " + file.Title + "\n\n" + content
+	}
+	return content
+}
+
+// isInlinedOnly returns true if the content of the file is added to the
+// message instead of attaching the file. Files whose content is not in the
+// export are attached as any other file.
+func (t *Transformer) isInlinedOnly(file *SlackFile, slackExport *SlackExport) bool {
+	return file.isInlined() && !t.KeepInlinedFiles && t.inlinedFileContent(file, slackExport) != ""
+}
+
+// AddInlinedFilesToPost adds the content of the snippets and Slack posts
+// shared in a post to its message. Content that doesn't fit in the message is
+// returned as replies to be added to the thread of the post.
+func (t *Transformer) AddInlinedFilesToPost(post *SlackPost, slackExport *SlackExport, newPost *IntermediatePost) []*IntermediatePost {
+	replies := []*IntermediatePost{}
+	for _, file := range post.inlinedFiles() {
+		content := t.inlinedFileContent(file, slackExport)
+		if content == "" {
+			t.Logger.Warnf("The content of file %s is not in the export, so it can't be added to the message", file.Id)
+			continue
+		}
+
+		message := content
+		if strings.TrimSpace(newPost.Message) != "" {
+			message = newPost.Message + "\n\n" + content
+		}
+		if utf8.RuneCountInString(message) <= model.PostMessageMaxRunesV2 {
+			newPost.Message = message
+			continue
+		}
+
+		if utf8.RuneCountInString(content) > model.PostMessageMaxRunesV2 {
+			t.Logger.Warnf("File %s exceeds the maximum post length and will be truncated", file.Id)
+			content = truncateRunes(content, model.PostMessageMaxRunesV2)
+		}
+		replies = append(replies, &IntermediatePost{
+			User:     newPost.User,
+			Channel:  newPost.Channel,
+			Message:  content,
+			CreateAt: newPost.CreateAt,
+		})
+	}
+	return replies
+}
+
+// addInlinedFileRepliesToThreads adds the replies created for the content of
+// files to the thread of the post.
+func addInlinedFileRepliesToThreads(original SlackPost, replies []*IntermediatePost, threads map[string]*IntermediatePost, channel *IntermediateChannel, timestamps map[int64]bool) {
+	threadTS := stringOrDefault(original.ThreadTS, original.TimeStamp)
+	for i, reply := range replies {
+		AddPostToThreads(SlackPost{TimeStamp: original.TimeStamp + "-file-" + strconv.Itoa(i), ThreadTS: threadTS}, reply, threads, channel, timestamps)
+	}
+}
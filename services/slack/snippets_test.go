@@ -0,0 +1,153 @@
+package slack
+
+import (
+	"archive/zip"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackConvertSnippet(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		File     SlackFile
+		Content  string
+		Expected string
+	}{
+		{
+			Name:     "Language from the file type",
+			File:     SlackFile{Name: "main.go", Filetype: "go"},
+			Content:  "package main\n",
+			Expected: "```go\npackage main\n```",
+		},
+		{
+			Name:     "Plain text",
+			File:     SlackFile{Filetype: "text"},
+			Content:  "just text",
+			Expected: "```\njust text\n```",
+		},
+		{
+			Name:     "Renamed language",
+			File:     SlackFile{Filetype: "shell"},
+			Content:  "ls -la",
+			Expected: "```bash\nls -la\n```",
+		},
+		{
+			Name:     "Content with a code fence",
+			File:     SlackFile{Filetype: "markdown"},
+			Content:  "```\ncode\n```",
+			Expected: "````markdown\n```\ncode\n```\n````",
+		},
+		{
+			Name:     "Title",
+			File:     SlackFile{Name: "deploy.sh", Title: "Deploy script", Filetype: "shell"},
+			Content:  "make deploy",
+			Expected: "**Deploy script**\n```bash\nmake deploy\n```",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, SlackConvertSnippet(&tc.File, tc.Content))
+		})
+	}
+}
+
+func TestAddInlinedFilesToPost(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackExport := &SlackExport{}
+
+	t.Run("Snippet added to the message", func(t *testing.T) {
+		post := &SlackPost{Files: []*SlackFile{{Id: "F1", Mode: "snippet", Filetype: "python", Preview: "print(1)"}}}
+		newPost := &IntermediatePost{Message: "Try this"}
+
+		replies := slackTransformer.AddInlinedFilesToPost(post, slackExport, newPost)
+		assert.Empty(t, replies)
+		assert.Equal(t, "Try this\n\n```python\nprint(1)\n```", newPost.Message)
+	})
+
+	t.Run("Slack post added to the message", func(t *testing.T) {
+		post := &SlackPost{File: &SlackFile{Id: "F2", Mode: "space", Title: "Release notes", PlainText: "Everything is faster"}}
+		newPost := &IntermediatePost{}
+
+		replies := slackTransformer.AddInlinedFilesToPost(post, slackExport, newPost)
+		assert.Empty(t, replies)
+		assert.Equal(t, "#### Release notes\n\nEverything is faster", newPost.Message)
+	})
+
+	t.Run("Long content as a reply", func(t *testing.T) {
+		content := strings.Repeat("a", model.PostMessageMaxRunesV2)
+		post := &SlackPost{Files: []*SlackFile{{Id: "F3", Mode: "snippet", Filetype: "text", PlainText: content}}}
+		newPost := &IntermediatePost{User: "alice", Channel: "general", Message: "Logs", CreateAt: 1600000000001}
+
+		replies := slackTransformer.AddInlinedFilesToPost(post, slackExport, newPost)
+		assert.Equal(t, "Logs", newPost.Message)
+		require.Len(t, replies, 1)
+		assert.Equal(t, "alice", replies[0].User)
+		assert.Equal(t, "general", replies[0].Channel)
+		assert.Len(t, []rune(replies[0].Message), model.PostMessageMaxRunesV2)
+	})
+
+	t.Run("Content missing", func(t *testing.T) {
+		post := &SlackPost{Files: []*SlackFile{{Id: "F4", Mode: "snippet"}}}
+		newPost := &IntermediatePost{Message: "See snippet"}
+
+		replies := slackTransformer.AddInlinedFilesToPost(post, slackExport, newPost)
+		assert.Empty(t, replies)
+		assert.Equal(t, "See snippet", newPost.Message)
+	})
+}
+
+func TestTransformPostsInlinedFiles(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.TransformUsers([]SlackUser{{Id: "U1", Username: "alice"}})
+	slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels([]SlackChannel{
+		{Id: "C1", Name: "general", Members: []string{"U1"}, Type: model.ChannelTypeOpen},
+	}, false)
+
+	slackExport := &SlackExport{
+		Posts: map[string][]SlackPost{
+			"general": {{
+				Type:      "message",
+				User:      "U1",
+				Text:      "Full logs",
+				TimeStamp: "1600000000.000100",
+				Files:     []*SlackFile{{Id: "F1", Name: "logs.txt", Mode: "snippet", Filetype: "text", PlainText: strings.Repeat("line\n", model.PostMessageMaxRunesV2/5)}},
+			}},
+		},
+	}
+	require.NoError(t, slackTransformer.TransformPosts(slackExport, "", false, false, false, false))
+
+	require.Len(t, slackTransformer.Intermediate.Posts, 1)
+	post := slackTransformer.Intermediate.Posts[0]
+	assert.Equal(t, "Full logs", post.Message)
+	assert.Empty(t, post.Attachments)
+	require.Len(t, post.Replies, 1)
+	assert.True(t, strings.HasPrefix(post.Replies[0].Message, "```\nline\n"))
+	assert.Greater(t, post.Replies[0].CreateAt, post.CreateAt)
+}
+
+func TestAddFilesToPostInlinedFileWithoutContent(t *testing.T) {
+	zipReader := createZipReader(t, map[string]string{"F1": "", "F2": "echo hello"})
+	uploads := map[string]*zip.File{}
+	for _, file := range zipReader.File {
+		uploads[file.Name] = file
+	}
+	slackExport := &SlackExport{Uploads: uploads}
+
+	slackTransformer := NewTransformer("test", log.New())
+	post := &SlackPost{Files: []*SlackFile{
+		{Id: "F1", Name: "empty.txt", Mode: "snippet", Filetype: "text"},
+		{Id: "F2", Name: "hello.sh", Mode: "snippet", Filetype: "shell"},
+	}}
+	newPost := &IntermediatePost{}
+	slackTransformer.AddFilesToPost(post, false, slackExport, t.TempDir(), newPost, false)
+
+	// only the snippet whose content can't be added to the message is attached
+	require.Len(t, newPost.Attachments, 1)
+	assert.Contains(t, newPost.Attachments[0], "F1")
+}
@@ -0,0 +1,215 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// SystemEventCategory groups the Slack system events that are handled the
+// same way.
+type SystemEventCategory string
+
+const (
+	SystemEventJoinLeave SystemEventCategory = "join_leave"
+	SystemEventTopic     SystemEventCategory = "topic"
+	SystemEventPurpose   SystemEventCategory = "purpose"
+	SystemEventRename    SystemEventCategory = "rename"
+	SystemEventMe        SystemEventCategory = "me"
+	SystemEventPin       SystemEventCategory = "pin"
+	SystemEventArchive   SystemEventCategory = "archive"
+)
+
+var SystemEventCategories = []SystemEventCategory{
+	SystemEventJoinLeave,
+	SystemEventTopic,
+	SystemEventPurpose,
+	SystemEventRename,
+	SystemEventMe,
+	SystemEventPin,
+	SystemEventArchive,
+}
+
+func (c SystemEventCategory) IsValid() bool {
+	for _, category := range SystemEventCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemEventsMode controls how the Slack system events of a category are
+// imported.
+type SystemEventsMode string
+
+const (
+	// SystemEventsKeep imports the events as regular messages of the user
+	// that caused them, with Slack's text.
+	SystemEventsKeep SystemEventsMode = "keep"
+	// SystemEventsConvert imports the events as the matching Mattermost
+	// system posts. Pin events have no Mattermost equivalent and are left
+	// out, as the pins are imported on the messages themselves.
+	SystemEventsConvert SystemEventsMode = "convert"
+	// SystemEventsSkip leaves the events out of the import.
+	SystemEventsSkip SystemEventsMode = "skip"
+)
+
+func (m SystemEventsMode) IsValid() bool {
+	return m == SystemEventsKeep || m == SystemEventsConvert || m == SystemEventsSkip
+}
+
+func (p *SlackPost) IsPinMessage() bool {
+	return p.Type == "message" && (p.SubType == "pinned_item" || p.SubType == "unpinned_item")
+}
+
+func (p *SlackPost) IsArchiveMessage() bool {
+	return p.Type == "message" && (p.SubType == "channel_archive" || p.SubType == "channel_unarchive" || p.SubType == "group_archive" || p.SubType == "group_unarchive")
+}
+
+// SystemEventCategory returns the category of the system event of the post,
+// or an empty string if the post is not a system event.
+func (p *SlackPost) SystemEventCategory() SystemEventCategory {
+	switch {
+	case p.IsJoinLeaveMessage():
+		return SystemEventJoinLeave
+	case p.IsChannelTopicMessage():
+		return SystemEventTopic
+	case p.IsChannelPurposeMessage():
+		return SystemEventPurpose
+	case p.IsChannelNameMessage():
+		return SystemEventRename
+	case p.IsMeMessage():
+		return SystemEventMe
+	case p.IsPinMessage():
+		return SystemEventPin
+	case p.IsArchiveMessage():
+		return SystemEventArchive
+	}
+	return ""
+}
+
+func (t *Transformer) systemEventsMode(category SystemEventCategory) SystemEventsMode {
+	if mode, ok := t.SystemEvents[category]; ok {
+		return mode
+	}
+	return SystemEventsConvert
+}
+
+// systemEventState holds the channel settings that system events change, as
+// Mattermost system posts show both the old and the new value.
+type systemEventState struct {
+	topic   string
+	purpose string
+}
+
+// convertSystemEvent turns the post into the Mattermost system post for its
+// event. It returns false if there is no such post.
+func (t *Transformer) convertSystemEvent(post SlackPost, newPost *IntermediatePost, state *systemEventState) bool {
+	props := model.StringInterface{"username": newPost.User}
+
+	switch post.SystemEventCategory() {
+	case SystemEventJoinLeave:
+		switch {
+		case strings.HasSuffix(post.SubType, "_leave"):
+			newPost.Type = model.PostTypeLeaveChannel
+			newPost.Message = fmt.Sprintf("%s left the channel.", newPost.User)
+		case post.Inviter != "" && post.Inviter != post.User:
+			inviter := t.Intermediate.UsersById[post.Inviter]
+			if inviter == nil {
+				t.CreateIntermediateUser(post.Inviter)
+				inviter = t.Intermediate.UsersById[post.Inviter]
+			}
+			newPost.Type = model.PostTypeAddToChannel
+			newPost.Message = fmt.Sprintf("%s added to the channel by %s.", newPost.User, inviter.Username)
+			props["username"] = inviter.Username
+			props["addedUsername"] = newPost.User
+		default:
+			newPost.Type = model.PostTypeJoinChannel
+			newPost.Message = fmt.Sprintf("%s joined the channel.", newPost.User)
+		}
+
+	case SystemEventTopic:
+		newPost.Type = model.PostTypeHeaderChange
+		newPost.Message = changeMessage(newPost.User, "channel header", state.topic, post.Topic)
+		props["old_header"] = state.topic
+		props["new_header"] = post.Topic
+		state.topic = post.Topic
+
+	case SystemEventPurpose:
+		newPost.Type = model.PostTypePurposeChange
+		newPost.Message = changeMessage(newPost.User, "channel purpose", state.purpose, post.Purpose)
+		props["old_purpose"] = state.purpose
+		props["new_purpose"] = post.Purpose
+		state.purpose = post.Purpose
+
+	case SystemEventRename:
+		newPost.Type = model.PostTypeDisplaynameChange
+		newPost.Message = changeMessage(newPost.User, "channel display name", post.OldName, post.Name)
+		props["old_displayname"] = post.OldName
+		props["new_displayname"] = post.Name
+
+	case SystemEventMe:
+		newPost.Type = model.PostTypeMe
+		newPost.Message = "*" + strings.TrimSpace(post.Text) + "*"
+		props = model.StringInterface{"message": post.Text}
+
+	case SystemEventArchive:
+		if strings.HasSuffix(post.SubType, "_unarchive") {
+			newPost.Type = model.PostTypeChannelRestored
+			newPost.Message = fmt.Sprintf("%s unarchived the channel", newPost.User)
+		} else {
+			newPost.Type = model.PostTypeChannelDeleted
+			newPost.Message = fmt.Sprintf("%s archived the channel", newPost.User)
+		}
+
+	default:
+		return false
+	}
+
+	if newPost.Props == nil {
+		newPost.Props = model.StringInterface{}
+	}
+	for key, value := range props {
+		newPost.Props[key] = value
+	}
+	return true
+}
+
+// changeMessage returns the message Mattermost posts when a channel setting
+// changes.
+func changeMessage(username, setting, oldValue, newValue string) string {
+	switch {
+	case oldValue == "":
+		return fmt.Sprintf("%s updated the %s to: %s", username, setting, newValue)
+	case newValue == "":
+		return fmt.Sprintf("%s removed the %s (was: %s)", username, setting, oldValue)
+	}
+	return fmt.Sprintf("%s updated the %s from: %s to: %s", username, setting, oldValue, newValue)
+}
+
+// CreateAndAddSystemPostToThreads imports a system event according to the
+// mode of its category.
+func (t *Transformer) CreateAndAddSystemPostToThreads(post SlackPost, state *systemEventState, threads map[string]*IntermediatePost, timestamps map[int64]bool, channel *IntermediateChannel, discardInvalidProps, addOriginal bool) {
+	category := post.SystemEventCategory()
+	switch t.systemEventsMode(category) {
+	case SystemEventsSkip:
+		t.Logger.Debugf("Skipping %s system event as %s events are skipped", post.SubType, category)
+		return
+	case SystemEventsKeep:
+		t.CreateAndAddPostToThreads(post, threads, timestamps, channel, discardInvalidProps, addOriginal)
+		return
+	}
+
+	newPost := t.createPost(post, channel, discardInvalidProps, addOriginal)
+	if newPost == nil {
+		return
+	}
+	if !t.convertSystemEvent(post, newPost, state) {
+		t.Logger.Debugf("Skipping %s system event as it has no Mattermost equivalent", post.SubType)
+		return
+	}
+
+	t.addPostToThreads(post, newPost, threads, channel, timestamps)
+}
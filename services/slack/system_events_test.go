@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformSystemEvents(t *testing.T) {
+	posts := []SlackPost{
+		{Type: "message", SubType: "channel_join", User: "U1", Text: "<@U1> has joined the channel", TimeStamp: "1600000000.000100"},
+		{Type: "message", SubType: "channel_join", User: "U2", Inviter: "U1", Text: "<@U2> has joined the channel", TimeStamp: "1600000001.000100"},
+		{Type: "message", SubType: "channel_topic", User: "U1", Topic: "Releases", Text: "set the channel topic: Releases", TimeStamp: "1600000002.000100"},
+		{Type: "message", SubType: "channel_topic", User: "U2", Topic: "Release train", Text: "set the channel topic: Release train", TimeStamp: "1600000003.000100"},
+		{Type: "message", SubType: "channel_purpose", User: "U1", Purpose: "Shipping", Text: "set the channel purpose: Shipping", TimeStamp: "1600000004.000100"},
+		{Type: "message", SubType: "channel_name", User: "U1", OldName: "general", Name: "releases", Text: "renamed the channel", TimeStamp: "1600000005.000100"},
+		{Type: "message", SubType: "me_message", User: "U2", Text: "waves", TimeStamp: "1600000006.000100"},
+		{Type: "message", SubType: "pinned_item", User: "U2", Text: "<@U2> pinned a message to this channel.", TimeStamp: "1600000007.000100"},
+		{Type: "message", SubType: "channel_archive", User: "U1", Text: "<@U1> archived the channel", TimeStamp: "1600000008.000100"},
+		{Type: "message", SubType: "channel_leave", User: "U2", Text: "<@U2> has left the channel", TimeStamp: "1600000009.000100"},
+	}
+
+	t.Run("Convert", func(t *testing.T) {
+		result := transformTestPosts(t, NewTransformer("test", log.New()), map[string][]SlackPost{"general": posts})
+		require.Len(t, result, 9)
+
+		expected := []struct {
+			Type    string
+			Message string
+			Props   model.StringInterface
+		}{
+			{model.PostTypeJoinChannel, "alice joined the channel.", model.StringInterface{"username": "alice"}},
+			{model.PostTypeAddToChannel, "bob added to the channel by alice.", model.StringInterface{"username": "alice", "addedUsername": "bob"}},
+			{model.PostTypeHeaderChange, "alice updated the channel header to: Releases", model.StringInterface{"username": "alice", "old_header": "", "new_header": "Releases"}},
+			{model.PostTypeHeaderChange, "bob updated the channel header from: Releases to: Release train", model.StringInterface{"username": "bob", "old_header": "Releases", "new_header": "Release train"}},
+			{model.PostTypePurposeChange, "alice updated the channel purpose to: Shipping", model.StringInterface{"username": "alice", "old_purpose": "", "new_purpose": "Shipping"}},
+			{model.PostTypeDisplaynameChange, "alice updated the channel display name from: general to: releases", model.StringInterface{"username": "alice", "old_displayname": "general", "new_displayname": "releases"}},
+			{model.PostTypeMe, "*waves*", model.StringInterface{"message": "waves"}},
+			{model.PostTypeChannelDeleted, "alice archived the channel", model.StringInterface{"username": "alice"}},
+			{model.PostTypeLeaveChannel, "bob left the channel.", model.StringInterface{"username": "bob"}},
+		}
+		for i, e := range expected {
+			assert.Equal(t, e.Type, result[i].Type)
+			assert.Equal(t, e.Message, result[i].Message)
+			assert.Equal(t, e.Props, result[i].Props)
+		}
+
+		line := GetImportLineFromPost(result[0], "test")
+		require.NotNil(t, line.Post.Type)
+		assert.Equal(t, model.PostTypeJoinChannel, *line.Post.Type)
+	})
+
+	t.Run("Keep and skip", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.SystemEvents = map[SystemEventCategory]SystemEventsMode{
+			SystemEventJoinLeave: SystemEventsSkip,
+			SystemEventTopic:     SystemEventsKeep,
+			SystemEventPurpose:   SystemEventsSkip,
+			SystemEventRename:    SystemEventsSkip,
+			SystemEventMe:        SystemEventsSkip,
+			SystemEventPin:       SystemEventsKeep,
+			SystemEventArchive:   SystemEventsSkip,
+		}
+		result := transformTestPosts(t, slackTransformer, map[string][]SlackPost{"general": posts})
+		require.Len(t, result, 3)
+
+		assert.Empty(t, result[0].Type)
+		assert.Equal(t, "set the channel topic: Releases", result[0].Message)
+		assert.Empty(t, result[2].Type)
+		assert.Equal(t, "<@U2> pinned a message to this channel.", result[2].Message)
+
+		line := GetImportLineFromPost(result[0], "test")
+		assert.Nil(t, line.Post.Type)
+	})
+}
@@ -0,0 +1,60 @@
+package slack
+
+import (
+	"strings"
+)
+
+// ThreadBroadcastsMode controls how replies that were also sent to the
+// channel are imported.
+type ThreadBroadcastsMode string
+
+const (
+	// ThreadBroadcastsReply imports broadcast replies only as replies.
+	ThreadBroadcastsReply ThreadBroadcastsMode = "reply"
+	// ThreadBroadcastsAlsoInChannel imports broadcast replies as replies and
+	// also as posts in the channel that quote the start of the thread.
+	ThreadBroadcastsAlsoInChannel ThreadBroadcastsMode = "also-in-channel"
+)
+
+func (m ThreadBroadcastsMode) IsValid() bool {
+	return m == ThreadBroadcastsReply || m == ThreadBroadcastsAlsoInChannel
+}
+
+const threadExcerptMaxRunes = 100
+
+func (p *SlackPost) IsThreadBroadcast() bool {
+	return p.Type == "message" && p.SubType == "thread_broadcast"
+}
+
+// threadExcerpt returns the first line of the message, shortened if needed.
+func threadExcerpt(message string) string {
+	excerpt := strings.TrimSpace(strings.SplitN(strings.TrimSpace(message), "\n", 2)[0])
+	if truncated := truncateRunes(excerpt, threadExcerptMaxRunes); truncated != excerpt {
+		excerpt = truncated + "…"
+	}
+	return excerpt
+}
+
+// addThreadBroadcastToChannel adds a copy of a broadcast reply as a post in
+// the channel, below the reply's text and quoting the root of its thread.
+func (t *Transformer) addThreadBroadcastToChannel(original SlackPost, reply *IntermediatePost, threads map[string]*IntermediatePost, channel *IntermediateChannel, timestamps map[int64]bool) {
+	if t.ThreadBroadcasts != ThreadBroadcastsAlsoInChannel || !original.IsThreadBroadcast() {
+		return
+	}
+	rootPost, ok := threads[original.ThreadTS]
+	if !ok {
+		return
+	}
+
+	message := reply.Message
+	if excerpt := threadExcerpt(rootPost.Message); excerpt != "" {
+		message += "\n\n> Replied to a thread: " + excerpt
+	}
+	channelPost := &IntermediatePost{
+		User:     reply.User,
+		Channel:  reply.Channel,
+		Message:  message,
+		CreateAt: reply.CreateAt,
+	}
+	AddPostToThreads(SlackPost{TimeStamp: original.TimeStamp + "-broadcast"}, channelPost, threads, channel, timestamps)
+}
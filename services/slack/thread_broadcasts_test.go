@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformThreadBroadcasts(t *testing.T) {
+	posts := []SlackPost{
+		{Type: "message", User: "U1", Text: "Deploy is starting\nDetails follow", TimeStamp: "1600000000.000100", ThreadTS: "1600000000.000100"},
+		{Type: "message", SubType: "thread_broadcast", User: "U2", Text: "Deploy is done", TimeStamp: "1600000001.000100", ThreadTS: "1600000000.000100"},
+	}
+
+	transform := func(mode ThreadBroadcastsMode) []*IntermediatePost {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.ThreadBroadcasts = mode
+		return transformTestPosts(t, slackTransformer, map[string][]SlackPost{"deploys": posts})
+	}
+
+	t.Run("Reply only", func(t *testing.T) {
+		result := transform(ThreadBroadcastsReply)
+		require.Len(t, result, 1)
+		require.Len(t, result[0].Replies, 1)
+		assert.Equal(t, "Deploy is done", result[0].Replies[0].Message)
+	})
+
+	t.Run("Also in channel", func(t *testing.T) {
+		result := transform(ThreadBroadcastsAlsoInChannel)
+		require.Len(t, result, 2)
+		require.Len(t, result[0].Replies, 1)
+		assert.Equal(t, "Deploy is done", result[0].Replies[0].Message)
+
+		channelPost := result[1]
+		assert.Equal(t, "bob", channelPost.User)
+		assert.Equal(t, "deploys", channelPost.Channel)
+		assert.Equal(t, "Deploy is done\n\n> Replied to a thread: Deploy is starting", channelPost.Message)
+		assert.Greater(t, channelPost.CreateAt, result[0].Replies[0].CreateAt)
+	})
+}
+
+func TestThreadExcerpt(t *testing.T) {
+	assert.Equal(t, "First line", threadExcerpt("  First line\nSecond line"))
+	assert.Equal(t, "", threadExcerpt(""))
+	assert.Equal(t, strings.Repeat("a", threadExcerptMaxRunes)+"…", threadExcerpt(strings.Repeat("a", threadExcerptMaxRunes+1)))
+}
@@ -6,6 +6,80 @@ type Transformer struct {
 	TeamName     string
 	Intermediate *Intermediate
 	Logger       log.FieldLogger
+	// ExportOwner is the Slack ID of the user from whose perspective the
+	// export being parsed was made. It is used to attribute starred items.
+	ExportOwner string
+	// AdminPolicy and GuestPolicy control how Slack workspace roles are
+	// translated into Mattermost roles.
+	AdminPolicy AdminPolicy
+	GuestPolicy GuestPolicy
+	// ArchivedChannels controls what happens with archived Slack channels.
+	// When it is ArchivedChannelsSeparateReport, the list of channels to
+	// archive is written to ArchivedChannelsReportPath.
+	ArchivedChannels           ArchivedChannelsMode
+	ArchivedChannelsReportPath string
+	// NicknameField is the Slack profile field used as the nickname, and
+	// FullNameFallbackField the one used for users without a first or last
+	// name.
+	NicknameField         NameField
+	FullNameFallbackField NameField
+	// TimezonesScriptPath is where the script that sets the timezones of
+	// the users after the import is written. No script is written when it
+	// is empty.
+	TimezonesScriptPath string
+	// Usergroups are the user groups supplied apart from the export. The
+	// definitions of the imported user groups are written to
+	// UsergroupsDefinitionPath.
+	Usergroups               []SlackUsergroup
+	UsergroupsDefinitionPath string
+	// Bookmarks are the channel bookmarks supplied apart from the export.
+	Bookmarks []SlackBookmark
+	// ExternalUsersAsGuests imports users from other organisations as
+	// guests. The list of external users is written to
+	// ExternalUsersReportPath.
+	ExternalUsersAsGuests   bool
+	ExternalUsersReportPath string
+	// SystemEvents holds how the Slack system events of each category are
+	// imported. Categories missing from it are converted.
+	SystemEvents map[SystemEventCategory]SystemEventsMode
+	// ThreadBroadcasts controls whether replies also sent to the channel
+	// are added to the channel as well.
+	ThreadBroadcasts ThreadBroadcastsMode
+	// OrphanedReplies controls how replies whose root post is missing from
+	// the export are imported.
+	OrphanedReplies OrphanedRepliesMode
+	// KeepInlinedFiles keeps the snippets and Slack posts whose content is
+	// added to the messages attached to them as well.
+	KeepInlinedFiles bool
+	// ExternalFilesReportPath is where the list of files shared from other
+	// services, which are imported as links, is written.
+	ExternalFilesReportPath string
+	// UnavailableContent controls what happens with content missing from the
+	// export. How much content is missing from each channel is written to
+	// UnavailableContentReportPath.
+	UnavailableContent           UnavailableContentMode
+	UnavailableContentReportPath string
+	// WorkspaceTeams holds the names of the existing Mattermost teams the
+	// workspaces of an Enterprise Grid export are imported into, by the
+	// directory of the workspace. Every workspace must have a team.
+	WorkspaceTeams map[string]string
+
+	// replacedRoots holds the posts that stand in for missing root posts
+	replacedRoots map[*IntermediatePost]bool
+	// deletedRoots holds the deleted messages left out of the import, by
+	// channel ID and timestamp
+	deletedRoots map[string]bool
+	// permalinkTargets holds the messages permalinks can point to, by
+	// channel ID and Slack timestamp
+	permalinkTargets map[string]permalinkTarget
+	// sharedCanvases holds the IDs of the canvases shared in messages
+	sharedCanvases map[string]bool
+	// workspaceTransformers hold the transformed workspaces of an
+	// Enterprise Grid export
+	workspaceTransformers []*Transformer
+	// workspaceMembers holds the Slack IDs of the users that belong to the
+	// workspace of an Enterprise Grid export
+	workspaceMembers map[string]bool
 }
 
 func NewTransformer(teamName string, logger log.FieldLogger) *Transformer {
@@ -13,5 +87,23 @@ func NewTransformer(teamName string, logger log.FieldLogger) *Transformer {
 		TeamName:     teamName,
 		Intermediate: &Intermediate{},
 		Logger:       logger,
+		AdminPolicy:  AdminPolicyTeam,
+		GuestPolicy:  GuestPolicyGuest,
+
+		ArchivedChannels:           ArchivedChannelsInclude,
+		ArchivedChannelsReportPath: DefaultArchivedChannelsReportPath,
+
+		NicknameField:         NameFieldDisplayName,
+		FullNameFallbackField: NameFieldRealName,
+
+		UsergroupsDefinitionPath: DefaultUsergroupsDefinitionPath,
+		ExternalUsersReportPath:  DefaultExternalUsersReportPath,
+		ExternalFilesReportPath:  DefaultExternalFilesReportPath,
+
+		ThreadBroadcasts: ThreadBroadcastsReply,
+		OrphanedReplies:  OrphanedRepliesPlaceholder,
+
+		UnavailableContent:           UnavailableContentPlaceholder,
+		UnavailableContentReportPath: DefaultUnavailableContentReportPath,
 	}
 }
@@ -0,0 +1,198 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// UnavailableContentMode controls what happens with content that Slack left
+// out of the export: files the exporting user couldn't access, deleted files
+// and messages, and files hidden by the limits of the Slack plan.
+type UnavailableContentMode string
+
+const (
+	// UnavailableContentPlaceholder adds a visible note where the content
+	// would be.
+	UnavailableContentPlaceholder UnavailableContentMode = "placeholder"
+	// UnavailableContentSilent leaves the content out without a note. The
+	// first reply of a deleted root post takes its place.
+	UnavailableContentSilent UnavailableContentMode = "silent"
+	// UnavailableContentFail stops the transformation if there is any
+	// unavailable content.
+	UnavailableContentFail UnavailableContentMode = "fail"
+)
+
+func (m UnavailableContentMode) IsValid() bool {
+	return m == UnavailableContentPlaceholder || m == UnavailableContentSilent || m == UnavailableContentFail
+}
+
+// UnavailableContentReason is why a piece of content is missing from the
+// export.
+type UnavailableContentReason string
+
+const (
+	UnavailableAccessDenied  UnavailableContentReason = "access_denied"
+	UnavailableDeletedFile   UnavailableContentReason = "deleted_file"
+	UnavailableHiddenByLimit UnavailableContentReason = "hidden_by_limit"
+	UnavailableDeletedPost   UnavailableContentReason = "deleted_message"
+)
+
+const DefaultUnavailableContentReportPath = "unavailable-content.json"
+
+// unavailableReason returns why the file is missing from the export, or an
+// empty string if it isn't.
+func (f *SlackFile) unavailableReason() UnavailableContentReason {
+	switch {
+	case f.Mode == "hidden_by_limit":
+		return UnavailableHiddenByLimit
+	case f.Mode == "tombstone" || f.FileAccess == "file_not_found":
+		return UnavailableDeletedFile
+	case f.Name == "" || f.FileAccess == "access_denied":
+		return UnavailableAccessDenied
+	}
+	return ""
+}
+
+func (p *SlackPost) IsTombstone() bool {
+	return p.Type == "message" && p.SubType == "tombstone"
+}
+
+// unavailableFilePlaceholder returns the note added to a message in place of
+// a file that is missing from the export.
+func unavailableFilePlaceholder(file *SlackFile) string {
+	details := stringOrDefault(file.Name, stringOrDefault(file.Title, file.Id))
+	if file.Size > 0 {
+		details += ", " + humanSize(file.Size)
+	}
+	return "[file unavailable in Slack export: " + details + "]"
+}
+
+const unavailablePostPlaceholder = "[message unavailable in Slack export]"
+
+// addDeletedPostToThreads adds a placeholder in place of a deleted message, so
+// its replies stay in their thread.
+func (t *Transformer) addDeletedPostToThreads(post SlackPost, threads map[string]*IntermediatePost, channel *IntermediateChannel, timestamps map[int64]bool) {
+	if post.User == "" {
+		t.Logger.Warn("Unable to import the deleted message as the user field is missing.")
+		return
+	}
+	author := t.Intermediate.UsersById[post.User]
+	if author == nil {
+		t.CreateIntermediateUser(post.User)
+		author = t.Intermediate.UsersById[post.User]
+	}
+
+	t.addPostToThreads(post, &IntermediatePost{
+		User:     author.Username,
+		Channel:  channel.Name,
+		Message:  unavailablePostPlaceholder,
+		CreateAt: SlackConvertTimeStamp(post.TimeStamp),
+	}, threads, channel, timestamps)
+}
+
+// recordDeletedRoot records that the deleted message was left out of the
+// import, so that the replies of its thread take its place.
+func (t *Transformer) recordDeletedRoot(post SlackPost, channel *IntermediateChannel) {
+	if t.deletedRoots == nil {
+		t.deletedRoots = map[string]bool{}
+	}
+	t.deletedRoots[channel.Id+"/"+post.TimeStamp] = true
+}
+
+// countUnavailableContent records that content of the channel is missing
+// from the export.
+func (t *Transformer) countUnavailableContent(channelName string, reason UnavailableContentReason) {
+	if t.Intermediate.UnavailableContent == nil {
+		t.Intermediate.UnavailableContent = map[string]map[UnavailableContentReason]int{}
+	}
+	if t.Intermediate.UnavailableContent[channelName] == nil {
+		t.Intermediate.UnavailableContent[channelName] = map[UnavailableContentReason]int{}
+	}
+	t.Intermediate.UnavailableContent[channelName][reason]++
+}
+
+// addUnavailableFileToPost counts the file as missing and adds a note about
+// it to the message of the post if placeholders are enabled.
+func (t *Transformer) addUnavailableFileToPost(file *SlackFile, reason UnavailableContentReason, newPost *IntermediatePost) {
+	t.countUnavailableContent(newPost.Channel, reason)
+	if t.UnavailableContent != UnavailableContentPlaceholder {
+		t.Logger.Debugf("Skipping file %s as it is not available in the export (%s)", file.Id, reason)
+		return
+	}
+
+	placeholder := unavailableFilePlaceholder(file)
+	if newPost.Message == "" {
+		newPost.Message = placeholder
+	} else {
+		newPost.Message += "\n\n" + placeholder
+	}
+}
+
+// checkUnavailableContent returns an error describing the content missing
+// from the export, if there is any.
+func checkUnavailableContent(slackExport *SlackExport) error {
+	count := 0
+	example := ""
+	for channelName, posts := range slackExport.Posts {
+		for _, post := range posts {
+			files := post.Files
+			if post.File != nil {
+				files = []*SlackFile{post.File}
+			}
+			for _, file := range files {
+				if reason := file.unavailableReason(); reason != "" {
+					count++
+					example = fmt.Sprintf("file %s in channel %s is not in the export (%s)", file.Id, channelName, reason)
+				}
+			}
+			if post.IsTombstone() {
+				count++
+				example = fmt.Sprintf("message %s in channel %s has been deleted", post.TimeStamp, channelName)
+			}
+		}
+	}
+
+	if count > 0 {
+		return errors.Errorf("found %d pieces of content missing from the Slack export, for example: %s", count, example)
+	}
+	return nil
+}
+
+// logUnavailableContent logs how much content of each channel is missing
+// from the export.
+func (t *Transformer) logUnavailableContent() {
+	channelNames := []string{}
+	for channelName := range t.Intermediate.UnavailableContent {
+		channelNames = append(channelNames, channelName)
+	}
+	sort.Strings(channelNames)
+
+	for _, channelName := range channelNames {
+		counts := t.Intermediate.UnavailableContent[channelName]
+		t.Logger.Warnf("Content of channel %s missing from the export: %d inaccessible files, %d deleted files, %d files hidden by plan limits, %d deleted messages",
+			channelName, counts[UnavailableAccessDenied], counts[UnavailableDeletedFile], counts[UnavailableHiddenByLimit], counts[UnavailableDeletedPost])
+	}
+}
+
+// ExportUnavailableContent writes how much content of each channel is
+// missing from the export, by the reason it is missing.
+func (t *Transformer) ExportUnavailableContent(writer io.Writer) error {
+	b, err := json.MarshalIndent(t.Intermediate.UnavailableContent, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "An error occurred marshalling the unavailable content.")
+	}
+
+	if _, err := writer.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "An error occurred writing the unavailable content.")
+	}
+
+	return nil
+}
+
+func (t *Transformer) ExportUnavailableContentReport(reportFilePath string) error {
+	return t.writeReport("the summary of unavailable content", reportFilePath, DefaultUnavailableContentReportPath, t.ExportUnavailableContent)
+}
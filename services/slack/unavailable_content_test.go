@@ -0,0 +1,96 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackFileUnavailableReason(t *testing.T) {
+	assert.Equal(t, UnavailableAccessDenied, (&SlackFile{Id: "F1"}).unavailableReason())
+	assert.Equal(t, UnavailableAccessDenied, (&SlackFile{Id: "F1", Name: "a.txt", FileAccess: "access_denied"}).unavailableReason())
+	assert.Equal(t, UnavailableDeletedFile, (&SlackFile{Id: "F1", Mode: "tombstone"}).unavailableReason())
+	assert.Equal(t, UnavailableDeletedFile, (&SlackFile{Id: "F1", Name: "a.txt", FileAccess: "file_not_found"}).unavailableReason())
+	assert.Equal(t, UnavailableHiddenByLimit, (&SlackFile{Id: "F1", Mode: "hidden_by_limit"}).unavailableReason())
+	assert.Empty(t, (&SlackFile{Id: "F1", Name: "a.txt", Mode: "hosted"}).unavailableReason())
+}
+
+func TestUnavailableFilePlaceholder(t *testing.T) {
+	assert.Equal(t, "[file unavailable in Slack export: report.pdf, 1.50 KiB]", unavailableFilePlaceholder(&SlackFile{Id: "F1", Name: "report.pdf", Size: 1536}))
+	assert.Equal(t, "[file unavailable in Slack export: F1]", unavailableFilePlaceholder(&SlackFile{Id: "F1"}))
+}
+
+func TestTransformPostsUnavailableContent(t *testing.T) {
+	posts := []SlackPost{
+		{Type: "message", SubType: "tombstone", User: "USLACKBOT", Text: "This message was deleted.", TimeStamp: "1600000000.000100", ThreadTS: "1600000000.000100"},
+		{Type: "message", User: "U1", Text: "Why was it deleted?", TimeStamp: "1600000000.000200", ThreadTS: "1600000000.000100"},
+		{Type: "message", User: "U1", Text: "Here it is", TimeStamp: "1600000001.000100", Files: []*SlackFile{
+			{Id: "F1", Name: "report.pdf", Size: 1536, FileAccess: "access_denied"},
+			{Id: "F2", Mode: "hidden_by_limit"},
+		}},
+	}
+
+	transform := func(mode UnavailableContentMode) (*Transformer, error) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.UnavailableContent = mode
+		slackTransformer.TransformUsers([]SlackUser{{Id: "U1", Username: "alice"}})
+		slackTransformer.Intermediate.PublicChannels = slackTransformer.TransformChannels([]SlackChannel{
+			{Id: "C1", Name: "general", Members: []string{"U1"}, Type: model.ChannelTypeOpen},
+		}, false)
+		err := slackTransformer.TransformPosts(&SlackExport{Posts: map[string][]SlackPost{"general": posts}}, "", false, false, false, false)
+		return slackTransformer, err
+	}
+
+	expectedCounts := map[string]map[UnavailableContentReason]int{
+		"general": {UnavailableAccessDenied: 1, UnavailableHiddenByLimit: 1, UnavailableDeletedPost: 1},
+	}
+
+	t.Run("Placeholder", func(t *testing.T) {
+		slackTransformer, err := transform(UnavailableContentPlaceholder)
+		require.NoError(t, err)
+		require.Len(t, slackTransformer.Intermediate.Posts, 2)
+
+		messages := []string{}
+		for _, post := range slackTransformer.Intermediate.Posts {
+			messages = append(messages, post.Message)
+			if post.Message == unavailablePostPlaceholder {
+				require.Len(t, post.Replies, 1)
+				assert.Equal(t, "Why was it deleted?", post.Replies[0].Message)
+				assert.Empty(t, post.Props)
+			}
+		}
+		assert.ElementsMatch(t, []string{
+			unavailablePostPlaceholder,
+			"Here it is\n\n[file unavailable in Slack export: report.pdf, 1.50 KiB]\n\n[file unavailable in Slack export: F2]",
+		}, messages)
+		assert.Equal(t, expectedCounts, slackTransformer.Intermediate.UnavailableContent)
+	})
+
+	t.Run("Silent", func(t *testing.T) {
+		slackTransformer, err := transform(UnavailableContentSilent)
+		require.NoError(t, err)
+		require.Len(t, slackTransformer.Intermediate.Posts, 2)
+
+		// the reply takes the place of the deleted root post, and is not
+		// counted as orphaned
+		messages := []string{}
+		for _, post := range slackTransformer.Intermediate.Posts {
+			messages = append(messages, post.Message)
+			assert.Empty(t, post.Attachments)
+			assert.Empty(t, post.Replies)
+		}
+		assert.ElementsMatch(t, []string{"Why was it deleted?", "Here it is"}, messages)
+		assert.Equal(t, expectedCounts, slackTransformer.Intermediate.UnavailableContent)
+		assert.Empty(t, slackTransformer.Intermediate.OrphanedReplies)
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		slackTransformer, err := transform(UnavailableContentFail)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found 3 pieces of content missing from the Slack export")
+		assert.Empty(t, slackTransformer.Intermediate.Posts)
+	})
+}
@@ -0,0 +1,166 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+const DefaultExternalUsersReportPath = "external-users.json"
+
+// SlackUserProfile is the profile Slack embeds in the messages of users, which
+// is the only information available for users missing from users.json, such
+// as Slack Connect users from other organisations.
+type SlackUserProfile struct {
+	Name              string `json:"name"`
+	RealName          string `json:"real_name"`
+	DisplayName       string `json:"display_name"`
+	FirstName         string `json:"first_name"`
+	Image72           string `json:"image_72"`
+	Team              string `json:"team"`
+	IsRestricted      bool   `json:"is_restricted"`
+	IsUltraRestricted bool   `json:"is_ultra_restricted"`
+}
+
+// SlackHarvestUserProfiles adds a user for each post author that is missing
+// from the users, built from the latest profile embedded in their posts.
+func SlackHarvestUserProfiles(users []SlackUser, posts map[string][]SlackPost) []SlackUser {
+	knownUsers := make(map[string]bool, len(users))
+	usernames := make(map[string]bool, len(users))
+	for _, user := range users {
+		knownUsers[user.Id] = true
+		usernames[user.Username] = true
+	}
+
+	type harvestedProfile struct {
+		profile   *SlackUserProfile
+		timestamp int64
+	}
+	profiles := map[string]harvestedProfile{}
+	for _, channelPosts := range posts {
+		for _, post := range channelPosts {
+			if post.UserProfile == nil || post.User == "" || knownUsers[post.User] {
+				continue
+			}
+			timestamp := SlackConvertTimeStampToMicroSeconds(post.TimeStamp)
+			if existing, ok := profiles[post.User]; !ok || timestamp > existing.timestamp {
+				profiles[post.User] = harvestedProfile{post.UserProfile, timestamp}
+			}
+		}
+	}
+
+	userIds := make([]string, 0, len(profiles))
+	for userId := range profiles {
+		userIds = append(userIds, userId)
+	}
+	sort.Strings(userIds)
+
+	for _, userId := range userIds {
+		profile := profiles[userId].profile
+		username := profile.Name
+		if username == "" || usernames[username] {
+			username = strings.ToLower(userId)
+		}
+		usernames[username] = true
+
+		users = append(users, SlackUser{
+			Id:       userId,
+			Username: username,
+			TeamId:   profile.Team,
+			Profile: SlackProfile{
+				FirstName:   profile.FirstName,
+				DisplayName: profile.DisplayName,
+				RealName:    profile.RealName,
+				// the embedded profile only has a small image
+				ImageOriginal: profile.Image72,
+			},
+			IsRestricted:      profile.IsRestricted,
+			IsUltraRestricted: profile.IsUltraRestricted,
+			FromUserProfile:   true,
+		})
+	}
+
+	return users
+}
+
+// organisationId returns the ID of the Enterprise Grid organisation of the
+// user, or of their workspace if it doesn't belong to one.
+func (u *SlackUser) organisationId() string {
+	if u.EnterpriseUser != nil && u.EnterpriseUser.EnterpriseId != "" {
+		return u.EnterpriseUser.EnterpriseId
+	}
+	return u.TeamId
+}
+
+// getHomeTeamId returns the ID of the Slack workspace, or Enterprise Grid
+// organisation, of the export, which is the one most of the users in
+// users.json belong to.
+func getHomeTeamId(users []SlackUser) string {
+	counts := map[string]int{}
+	for _, user := range users {
+		if user.organisationId() != "" && !user.FromUserProfile {
+			counts[user.organisationId()]++
+		}
+	}
+
+	homeTeamId := ""
+	for teamId, count := range counts {
+		if count > counts[homeTeamId] || count == counts[homeTeamId] && teamId < homeTeamId {
+			homeTeamId = teamId
+		}
+	}
+	return homeTeamId
+}
+
+// isExternalUser returns true if the user belongs to another organisation than
+// the workspace of the export.
+func isExternalUser(user SlackUser, homeTeamId string) bool {
+	return homeTeamId != "" && user.organisationId() != "" && user.organisationId() != homeTeamId
+}
+
+type ExternalUser struct {
+	Username string `json:"username"`
+	SlackId  string `json:"slack_id"`
+	HomeTeam string `json:"home_team"`
+	IsGuest  bool   `json:"is_guest"`
+}
+
+func (t *Transformer) getExternalUsers() []ExternalUser {
+	externalUsers := []ExternalUser{}
+	for _, user := range t.Intermediate.UsersById {
+		if user.HomeTeam == "" {
+			continue
+		}
+		externalUsers = append(externalUsers, ExternalUser{
+			Username: user.Username,
+			SlackId:  user.Id,
+			HomeTeam: user.HomeTeam,
+			IsGuest:  user.Roles == model.SystemGuestRoleId,
+		})
+	}
+	sort.Slice(externalUsers, func(i, j int) bool { return externalUsers[i].Username < externalUsers[j].Username })
+	return externalUsers
+}
+
+// ExportExternalUsers writes the list of imported users that belong to other
+// organisations, along with the Slack workspace they belong to.
+func (t *Transformer) ExportExternalUsers(writer io.Writer) error {
+	b, err := json.MarshalIndent(t.getExternalUsers(), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "An error occurred marshalling the external users.")
+	}
+
+	if _, err := writer.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "An error occurred writing the external users.")
+	}
+
+	return nil
+}
+
+func (t *Transformer) ExportExternalUsersReport(reportFilePath string) error {
+	return t.writeReport("the list of external users", reportFilePath, DefaultExternalUsersReportPath, t.ExportExternalUsers)
+}
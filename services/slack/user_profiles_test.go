@@ -0,0 +1,86 @@
+package slack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackHarvestUserProfiles(t *testing.T) {
+	users := []SlackUser{{Id: "U1", Username: "alice", TeamId: "T1"}}
+	posts := map[string][]SlackPost{
+		"general": {
+			{User: "U1", TimeStamp: "1600000000.000100", UserProfile: &SlackUserProfile{Name: "alice"}},
+			{User: "U2", TimeStamp: "1600000002.000100", UserProfile: &SlackUserProfile{Name: "bob", RealName: "Bob Smith", Team: "T2", Image72: "https://example.com/bob_72.png"}},
+			{User: "U2", TimeStamp: "1600000001.000100", UserProfile: &SlackUserProfile{Name: "bob", RealName: "Robert Smith", Team: "T2"}},
+			{User: "U3", TimeStamp: "1600000003.000100", UserProfile: &SlackUserProfile{Name: "alice", Team: "T2"}},
+			{User: "U4", TimeStamp: "1600000004.000100"},
+		},
+	}
+
+	users = SlackHarvestUserProfiles(users, posts)
+
+	require.Len(t, users, 3)
+	bob := users[1]
+	assert.Equal(t, "U2", bob.Id)
+	assert.Equal(t, "bob", bob.Username)
+	assert.Equal(t, "T2", bob.TeamId)
+	assert.Equal(t, "Bob Smith", bob.Profile.RealName)
+	assert.Equal(t, "https://example.com/bob_72.png", bob.Profile.ImageOriginal)
+	assert.True(t, bob.FromUserProfile)
+	// the username is already taken
+	assert.Equal(t, "u3", users[2].Username)
+}
+
+func TestTransformExternalUsers(t *testing.T) {
+	users := []SlackUser{
+		{Id: "U1", Username: "alice", TeamId: "T1"},
+		{Id: "U2", Username: "carol", TeamId: "T1"},
+		{Id: "U3", Username: "bob", TeamId: "T2", FromUserProfile: true, Profile: SlackProfile{RealName: "Bob Smith"}},
+	}
+
+	t.Run("External users are regular users by default", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.TransformUsers(users)
+
+		bob := slackTransformer.Intermediate.UsersById["U3"]
+		assert.Equal(t, "T2", bob.HomeTeam)
+		assert.Equal(t, "Bob", bob.FirstName)
+		assert.Equal(t, "Smith", bob.LastName)
+		assert.Equal(t, model.SystemUserRoleId, bob.Roles)
+		assert.Empty(t, slackTransformer.Intermediate.UsersById["U1"].HomeTeam)
+	})
+
+	t.Run("External users as guests", func(t *testing.T) {
+		slackTransformer := NewTransformer("test", log.New())
+		slackTransformer.ExternalUsersAsGuests = true
+		slackTransformer.TransformUsers(users)
+
+		bob := slackTransformer.Intermediate.UsersById["U3"]
+		assert.Equal(t, model.SystemGuestRoleId, bob.Roles)
+		assert.Equal(t, model.TeamGuestRoleId, bob.TeamRoles)
+		assert.Equal(t, model.ChannelGuestRoleId, bob.ChannelRoles)
+		assert.Equal(t, model.SystemUserRoleId, slackTransformer.Intermediate.UsersById["U1"].Roles)
+
+		var b bytes.Buffer
+		require.NoError(t, slackTransformer.ExportExternalUsers(&b))
+		assert.JSONEq(t, `[{"username": "bob", "slack_id": "U3", "home_team": "T2", "is_guest": true}]`, b.String())
+	})
+}
+
+func TestMergeUsersFromUserProfile(t *testing.T) {
+	listed := SlackUser{Id: "U1", Username: "bob", TeamId: "T2", Profile: SlackProfile{Email: "bob@example.com"}}
+	harvested := SlackUser{Id: "U1", Username: "bob", TeamId: "T2", FromUserProfile: true}
+
+	merged, err := mergeUsers([]SlackUser{harvested}, []SlackUser{listed})
+	require.NoError(t, err)
+	assert.Equal(t, []SlackUser{listed}, merged)
+
+	merged, err = mergeUsers([]SlackUser{listed}, []SlackUser{harvested})
+	require.NoError(t, err)
+	assert.Equal(t, []SlackUser{listed}, merged)
+}
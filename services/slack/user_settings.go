@@ -0,0 +1,112 @@
+package slack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// supportedLocales are the locales Mattermost has translations for.
+var supportedLocales = []string{
+	"bg", "de", "en", "en-AU", "es", "fa", "fr", "hu", "it", "ja", "ko", "nl",
+	"pl", "pt-BR", "ro", "ru", "sv", "tr", "uk", "vi", "zh-CN", "zh-TW",
+}
+
+// SlackConvertLocale returns the Mattermost locale closest to the given Slack
+// locale, such as en-US, or an empty string if there is none so that the
+// server default is used.
+func SlackConvertLocale(slackLocale string) string {
+	if slackLocale == "" {
+		return ""
+	}
+	slackLocale = strings.ReplaceAll(slackLocale, "_", "-")
+	for _, locale := range supportedLocales {
+		if strings.EqualFold(locale, slackLocale) {
+			return locale
+		}
+	}
+	// Traditional Chinese is also used in Hong Kong and Macau
+	switch strings.ToLower(slackLocale) {
+	case "zh-hk", "zh-mo", "zh-hant":
+		return "zh-TW"
+	case "zh-sg", "zh-hans":
+		return "zh-CN"
+	}
+	language := strings.SplitN(slackLocale, "-", 2)[0]
+	for _, locale := range supportedLocales {
+		if strings.EqualFold(locale, language) {
+			return locale
+		}
+	}
+	return ""
+}
+
+var isValidTimezoneName = regexp.MustCompile(`^[A-Za-z0-9_+\-/]+$`).MatchString
+
+// SlackConvertTimezone returns the IANA name of the user's timezone. Without
+// a valid name, a whole hour offset in seconds is turned into the matching
+// Etc/GMT zone, and otherwise an empty string is returned.
+func SlackConvertTimezone(tz string, offset int) string {
+	if isValidTimezoneName(tz) {
+		return tz
+	}
+	if offset == 0 || offset%3600 != 0 {
+		return ""
+	}
+	// the sign of Etc/GMT zones is inverted
+	return fmt.Sprintf("Etc/GMT%+d", -offset/3600)
+}
+
+const timezonesScriptHeader = `#!/bin/sh
+# Sets the timezones the users had in Slack, as the bulk import format can't.
+# Run it after the import with MM_URL set to the URL of the Mattermost server
+# and MM_TOKEN to a personal access token of a system admin.
+set -e
+
+set_timezone() {
+	user_id=$(curl -sSf -H "Authorization: Bearer $MM_TOKEN" "$MM_URL/api/v4/users/username/$1" | sed -n 's/^{"id":"\([a-z0-9]*\)".*/\1/p')
+	if [ -z "$user_id" ]; then
+		echo "User $1 not found" >&2
+		return
+	fi
+	curl -sSf -o /dev/null -X PUT -H "Authorization: Bearer $MM_TOKEN" "$MM_URL/api/v4/users/$user_id/patch" \
+		-d "{\"timezone\": {\"useAutomaticTimezone\": \"false\", \"manualTimezone\": \"$2\", \"automaticTimezone\": \"\"}}"
+}
+
+`
+
+// ExportTimezonesScript writes a script that sets the timezones of the users
+// after the import.
+func (t *Transformer) ExportTimezonesScript(writer io.Writer) error {
+	users := []*IntermediateUser{}
+	for _, user := range t.Intermediate.UsersById {
+		if user.Timezone != "" {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+
+	if _, err := io.WriteString(writer, timezonesScriptHeader); err != nil {
+		return err
+	}
+	for _, user := range users {
+		if _, err := fmt.Fprintf(writer, "set_timezone '%s' '%s'\n", user.Username, user.Timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transformer) ExportTimezonesScriptFile(scriptFilePath string) error {
+	scriptFile, err := os.OpenFile(scriptFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	defer scriptFile.Close()
+
+	t.Logger.Infof("Exporting the user timezones script to %s", scriptFilePath)
+	return t.ExportTimezonesScript(scriptFile)
+}
@@ -0,0 +1,65 @@
+package slack
+
+import (
+	"bytes"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackConvertLocale(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Locale   string
+		Expected string
+	}{
+		{"Empty locale", "", ""},
+		{"Exact match", "pt-BR", "pt-BR"},
+		{"Different case", "zh-cn", "zh-CN"},
+		{"Underscore separator", "en_AU", "en-AU"},
+		{"Region not supported", "en-US", "en"},
+		{"Language only", "de", "de"},
+		{"Traditional Chinese", "zh-HK", "zh-TW"},
+		{"Language not supported", "fi-FI", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, SlackConvertLocale(tc.Locale))
+		})
+	}
+}
+
+func TestSlackConvertTimezone(t *testing.T) {
+	assert.Equal(t, "Europe/Stockholm", SlackConvertTimezone("Europe/Stockholm", 7200))
+	assert.Equal(t, "Etc/GMT-2", SlackConvertTimezone("", 7200))
+	assert.Equal(t, "Etc/GMT+5", SlackConvertTimezone("", -18000))
+	assert.Equal(t, "", SlackConvertTimezone("", 19800))
+	assert.Equal(t, "", SlackConvertTimezone("", 0))
+	assert.Equal(t, "", SlackConvertTimezone("Europe/Stockholm'; rm -rf /", 0))
+}
+
+func TestTransformUserSettings(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.TransformUsers([]SlackUser{
+		{Id: "U1", Username: "alice", TZ: "Europe/Berlin", Locale: "de-DE"},
+		{Id: "U2", Username: "bob", TZOffset: -18000},
+		{Id: "U3", Username: "carol"},
+	})
+
+	alice := slackTransformer.Intermediate.UsersById["U1"]
+	assert.Equal(t, "de", alice.Locale)
+	assert.Equal(t, "Europe/Berlin", alice.Timezone)
+	assert.Equal(t, "de", *GetImportLineFromUser(alice, "test").User.Locale)
+
+	carol := slackTransformer.Intermediate.UsersById["U3"]
+	assert.Nil(t, GetImportLineFromUser(carol, "test").User.Locale)
+
+	var b bytes.Buffer
+	require.NoError(t, slackTransformer.ExportTimezonesScript(&b))
+	script := b.String()
+	assert.Contains(t, script, "set_timezone 'alice' 'Europe/Berlin'\nset_timezone 'bob' 'Etc/GMT+5'\n")
+	assert.NotContains(t, script, "carol")
+}
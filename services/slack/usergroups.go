@@ -0,0 +1,188 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const DefaultUsergroupsDefinitionPath = "user-groups.json"
+
+type SlackUsergroup struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Handle      string   `json:"handle"`
+	Description string   `json:"description"`
+	DateDelete  int64    `json:"date_delete"`
+	Users       []string `json:"users"`
+}
+
+type IntermediateUsergroup struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"`
+	Description string   `json:"description"`
+	Members     []string `json:"members"`
+}
+
+// slackUsergroupList is the shape of the response of Slack's usergroups.list
+// method.
+type slackUsergroupList struct {
+	Usergroups []SlackUsergroup `json:"usergroups"`
+}
+
+// SlackParseUsergroups reads user groups either from a usergroups.list
+// response or from a plain array of user groups.
+func SlackParseUsergroups(data io.Reader) ([]SlackUsergroup, error) {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var usergroupList slackUsergroupList
+	if err := json.Unmarshal(b, &usergroupList); err == nil {
+		return usergroupList.Usergroups, nil
+	}
+
+	var usergroups []SlackUsergroup
+	if err := json.Unmarshal(b, &usergroups); err != nil {
+		return nil, errors.Wrap(err, "user groups file must contain a usergroups.list response or an array of user groups")
+	}
+	return usergroups, nil
+}
+
+// ParseUsergroupsFile reads user groups supplied apart from the export. They
+// are used in addition to the ones in the export, which take precedence.
+func (t *Transformer) ParseUsergroupsFile(usergroupsFile string) error {
+	file, err := os.Open(usergroupsFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	t.Usergroups, err = SlackParseUsergroups(file)
+	if err != nil {
+		t.Logger.Error(err.Error())
+		return err
+	}
+	t.Logger.Infof("Parsed %d user groups", len(t.Usergroups))
+	return nil
+}
+
+// mergeSuppliedUsergroups adds the supplied user groups that are not already
+// in the export.
+func mergeSuppliedUsergroups(exported, supplied []SlackUsergroup) []SlackUsergroup {
+	ids := make(map[string]bool, len(exported))
+	for _, usergroup := range exported {
+		ids[usergroup.Id] = true
+	}
+	for _, usergroup := range supplied {
+		if !ids[usergroup.Id] {
+			exported = append(exported, usergroup)
+		}
+	}
+	return exported
+}
+
+var subteamMentionRegex = regexp.MustCompile(`<!subteam\^([A-Z0-9]+)(?:\|@?([^>]*))?>`)
+
+// SlackConvertUsergroupMentions replaces mentions of user groups with the
+// handle of the group. Mentions of unknown groups use the label of the
+// mention, if any.
+func SlackConvertUsergroupMentions(usergroups []SlackUsergroup, posts map[string][]SlackPost) map[string][]SlackPost {
+	handles := make(map[string]string, len(usergroups))
+	for _, usergroup := range usergroups {
+		if usergroup.Handle != "" {
+			handles[usergroup.Id] = usergroup.Handle
+		}
+	}
+
+	replace := func(mention string) string {
+		matches := subteamMentionRegex.FindStringSubmatch(mention)
+		if handle, ok := handles[matches[1]]; ok {
+			return "@" + handle
+		}
+		if matches[2] != "" {
+			return "@" + matches[2]
+		}
+		return mention
+	}
+
+	for channelName, channelPosts := range posts {
+		for postIdx, post := range channelPosts {
+			if !strings.Contains(post.Text, "<!subteam^") {
+				continue
+			}
+			posts[channelName][postIdx].Text = subteamMentionRegex.ReplaceAllStringFunc(post.Text, replace)
+		}
+	}
+
+	return posts
+}
+
+// TransformUsergroups builds the definitions of the user groups to recreate
+// as custom groups. Deleted groups are left out, and so are members that are
+// not imported.
+func (t *Transformer) TransformUsergroups(usergroups []SlackUsergroup) {
+	t.Intermediate.Usergroups = []*IntermediateUsergroup{}
+	if len(usergroups) == 0 {
+		return
+	}
+	t.Logger.Info("Transforming user groups")
+
+	for _, usergroup := range usergroups {
+		if usergroup.DateDelete != 0 {
+			t.Logger.Debugf("Skipping deleted user group %s", usergroup.Handle)
+			continue
+		}
+		if usergroup.Handle == "" {
+			t.Logger.Warnf("Skipping user group %s as it has no handle", usergroup.Id)
+			continue
+		}
+
+		members := []string{}
+		for _, userId := range usergroup.Users {
+			user, ok := t.Intermediate.UsersById[userId]
+			if !ok {
+				t.Logger.Warnf("User %s of user group %s is not part of the import", userId, usergroup.Handle)
+				continue
+			}
+			members = append(members, user.Username)
+		}
+		sort.Strings(members)
+
+		t.Intermediate.Usergroups = append(t.Intermediate.Usergroups, &IntermediateUsergroup{
+			Name:        usergroup.Handle,
+			DisplayName: stringOrDefault(usergroup.Name, usergroup.Handle),
+			Description: usergroup.Description,
+			Members:     members,
+		})
+	}
+
+	sort.Slice(t.Intermediate.Usergroups, func(i, j int) bool {
+		return t.Intermediate.Usergroups[i].Name < t.Intermediate.Usergroups[j].Name
+	})
+}
+
+// ExportUsergroups writes the definitions of the user groups, so that they
+// can be recreated as custom groups after the import.
+func (t *Transformer) ExportUsergroups(writer io.Writer) error {
+	b, err := json.MarshalIndent(t.Intermediate.Usergroups, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "An error occurred marshalling the user groups.")
+	}
+
+	if _, err := writer.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "An error occurred writing the user groups.")
+	}
+
+	return nil
+}
+
+func (t *Transformer) ExportUsergroupsDefinition(definitionFilePath string) error {
+	return t.writeReport("the user groups", definitionFilePath, DefaultUsergroupsDefinitionPath, t.ExportUsergroups)
+}
@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackParseUsergroups(t *testing.T) {
+	t.Run("From a usergroups.list response", func(t *testing.T) {
+		usergroups, err := SlackParseUsergroups(strings.NewReader(`{
+			"ok": true,
+			"usergroups": [{"id": "S1", "handle": "oncall", "users": ["U1"]}]
+		}`))
+		require.NoError(t, err)
+		require.Len(t, usergroups, 1)
+		assert.Equal(t, "oncall", usergroups[0].Handle)
+		assert.Equal(t, []string{"U1"}, usergroups[0].Users)
+	})
+
+	t.Run("From an array", func(t *testing.T) {
+		usergroups, err := SlackParseUsergroups(strings.NewReader(`[{"id": "S1", "handle": "oncall"}, {"id": "S2", "handle": "design"}]`))
+		require.NoError(t, err)
+		assert.Len(t, usergroups, 2)
+	})
+
+	t.Run("Invalid file", func(t *testing.T) {
+		_, err := SlackParseUsergroups(strings.NewReader(`"oncall"`))
+		assert.Error(t, err)
+	})
+}
+
+func TestSlackConvertUsergroupMentions(t *testing.T) {
+	usergroups := []SlackUsergroup{{Id: "S1", Handle: "oncall"}}
+	posts := map[string][]SlackPost{
+		"general": {
+			{Text: "<!subteam^S1|@oncall> please look"},
+			{Text: "ping <!subteam^S1>"},
+			{Text: "ping <!subteam^S2|@design>"},
+			{Text: "ping <!subteam^S3>"},
+		},
+	}
+
+	posts = SlackConvertUsergroupMentions(usergroups, posts)
+
+	assert.Equal(t, "@oncall please look", posts["general"][0].Text)
+	assert.Equal(t, "ping @oncall", posts["general"][1].Text)
+	assert.Equal(t, "ping @design", posts["general"][2].Text)
+	assert.Equal(t, "ping <!subteam^S3>", posts["general"][3].Text)
+}
+
+func TestTransformUsergroups(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.TransformUsers([]SlackUser{
+		{Id: "U1", Username: "alice"},
+		{Id: "U2", Username: "bob"},
+	})
+
+	slackTransformer.TransformUsergroups([]SlackUsergroup{
+		{Id: "S1", Handle: "oncall", Name: "On-call", Description: "Who to page", Users: []string{"U2", "U1", "U3"}},
+		{Id: "S2", Handle: "old", DateDelete: 1600000000},
+		{Id: "S3", Handle: "design"},
+	})
+
+	require.Len(t, slackTransformer.Intermediate.Usergroups, 2)
+	assert.Equal(t, &IntermediateUsergroup{Name: "design", DisplayName: "design", Members: []string{}}, slackTransformer.Intermediate.Usergroups[0])
+	assert.Equal(t, &IntermediateUsergroup{
+		Name:        "oncall",
+		DisplayName: "On-call",
+		Description: "Who to page",
+		Members:     []string{"alice", "bob"},
+	}, slackTransformer.Intermediate.Usergroups[1])
+
+	var b bytes.Buffer
+	require.NoError(t, slackTransformer.ExportUsergroups(&b))
+	assert.Contains(t, b.String(), `"members": [
+      "alice",
+      "bob"
+    ]`)
+}
+
+func TestMergeUsergroups(t *testing.T) {
+	merged, err := mergeUsergroups(
+		[]SlackUsergroup{{Id: "S1", Handle: "oncall", Users: []string{"U1"}}},
+		[]SlackUsergroup{{Id: "S1", Handle: "oncall", Users: []string{"U2"}}},
+	)
+	require.NoError(t, err)
+	require.Len(t, merged, 1)
+	assert.ElementsMatch(t, []string{"U1", "U2"}, merged[0].Users)
+
+	_, err = mergeUsergroups(
+		[]SlackUsergroup{{Id: "S1", Handle: "oncall"}},
+		[]SlackUsergroup{{Id: "S1", Handle: "support"}},
+	)
+	assert.Error(t, err)
+}